@@ -0,0 +1,246 @@
+// Package apps finds the installed desktop entries of applications, so the
+// shell can map the app_id of a running window to its name and icon.
+package apps
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is the part of a desktop entry the shell uses.
+type Entry struct {
+	// ID is the desktop file ID without the .desktop suffix, for example
+	// "org.gnome.Nautilus".
+	ID             string
+	Path           string
+	Name           string
+	Icon           string
+	Exec           string
+	StartupWMClass string
+	NoDisplay      bool
+	// Actions are the additional actions of the application listed by
+	// the Actions key, such as "New Private Window".
+	Actions []Action
+}
+
+// Action is a desktop action of an application.
+type Action struct {
+	ID   string
+	Name string
+	Icon string
+	Exec string
+}
+
+// reloadInterval limits how often a failed lookup rescans the disk for
+// newly installed applications.
+const reloadInterval = 30 * time.Second
+
+var index struct {
+	sync.Mutex
+	entries  []Entry
+	loadedAt time.Time
+}
+
+// Lookup returns the desktop entry of the application with the given
+// Wayland app_id or X11 class. It tries the desktop file ID, the
+// StartupWMClass key and the last component of reverse-DNS IDs, ignoring
+// case.
+func Lookup(appID string) (Entry, bool) {
+	if appID == "" {
+		return Entry{}, false
+	}
+
+	index.Lock()
+	defer index.Unlock()
+
+	if index.entries == nil {
+		reload()
+	}
+	if e, ok := match(index.entries, appID); ok {
+		return e, true
+	}
+
+	if time.Since(index.loadedAt) < reloadInterval {
+		return Entry{}, false
+	}
+	reload()
+	return match(index.entries, appID)
+}
+
+// All returns every installed application, including hidden ones.
+func All() []Entry {
+	index.Lock()
+	defer index.Unlock()
+
+	if index.entries == nil {
+		reload()
+	}
+	return slices.Clone(index.entries)
+}
+
+// reload rescans the disk. index must be locked.
+func reload() {
+	index.entries = load()
+	index.loadedAt = time.Now()
+}
+
+func match(entries []Entry, appID string) (Entry, bool) {
+	for _, e := range entries {
+		if e.ID == appID {
+			return e, true
+		}
+	}
+	for _, e := range entries {
+		if strings.EqualFold(e.ID, appID) || strings.EqualFold(e.StartupWMClass, appID) {
+			return e, true
+		}
+	}
+	for _, e := range entries {
+		if strings.EqualFold(e.ID[strings.LastIndex(e.ID, ".")+1:], appID) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// dataDirs returns the XDG data directories in order of precedence.
+func dataDirs() []string {
+	home := os.Getenv("XDG_DATA_HOME")
+	if home == "" {
+		if h, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(h, ".local", "share")
+		}
+	}
+
+	dirs := os.Getenv("XDG_DATA_DIRS")
+	if dirs == "" {
+		dirs = "/usr/local/share:/usr/share"
+	}
+
+	var result []string
+	if home != "" {
+		result = append(result, home)
+	}
+	for _, d := range strings.Split(dirs, ":") {
+		if d != "" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func load() []Entry {
+	var entries []Entry
+	seen := make(map[string]bool)
+	for _, dir := range dataDirs() {
+		root := filepath.Join(dir, "applications")
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".desktop") {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			id := strings.ReplaceAll(strings.TrimSuffix(rel, ".desktop"), string(filepath.Separator), "-")
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+
+			e, ok := parse(path)
+			if ok {
+				e.ID = id
+				entries = append(entries, e)
+			}
+			return nil
+		})
+	}
+	return entries
+}
+
+// parse reads the [Desktop Entry] and [Desktop Action] groups of the file at
+// path. It reports false for hidden entries and files that are not
+// applications.
+func parse(path string) (Entry, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Entry{}, false
+	}
+	defer f.Close()
+
+	e := Entry{Path: path}
+	group := ""
+	typ := ""
+	hidden := false
+	var actionIDs []string
+	actions := make(map[string]*Action)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			group = strings.Trim(line, "[]")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if id, ok := strings.CutPrefix(group, "Desktop Action "); ok {
+			a := actions[id]
+			if a == nil {
+				a = &Action{ID: id}
+				actions[id] = a
+			}
+			switch key {
+			case "Name":
+				a.Name = value
+			case "Icon":
+				a.Icon = value
+			case "Exec":
+				a.Exec = value
+			}
+			continue
+		}
+		if group != "Desktop Entry" {
+			continue
+		}
+
+		switch key {
+		case "Type":
+			typ = value
+		case "Name":
+			e.Name = value
+		case "Icon":
+			e.Icon = value
+		case "Exec":
+			e.Exec = value
+		case "StartupWMClass":
+			e.StartupWMClass = value
+		case "NoDisplay":
+			e.NoDisplay = value == "true"
+		case "Hidden":
+			hidden = value == "true"
+		case "Actions":
+			actionIDs = strings.Split(strings.TrimSuffix(value, ";"), ";")
+		}
+	}
+
+	for _, id := range actionIDs {
+		if a, ok := actions[id]; ok && a.Name != "" && a.Exec != "" {
+			e.Actions = append(e.Actions, *a)
+		}
+	}
+
+	return e, typ == "Application" && !hidden
+}
@@ -0,0 +1,81 @@
+package apps
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Launch runs the desktop action a of the application e.
+func (e Entry) Launch(a Action) error {
+	args, err := expandExec(a.Exec, e)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the process once it exits.
+	go cmd.Wait()
+	return nil
+}
+
+// expandExec splits an Exec value into arguments following the quoting rules
+// of the desktop entry specification. Field codes for files and URLs are
+// dropped since the shell never passes any.
+func expandExec(value string, e Entry) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted := false, false
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(value):
+			i++
+			arg.WriteByte(value[i])
+		case c == '"':
+			quoted = !quoted
+			inArg = true
+		case !quoted && (c == ' ' || c == '\t'):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '%' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case '%':
+				arg.WriteByte('%')
+				inArg = true
+			case 'c':
+				arg.WriteString(e.Name)
+				inArg = true
+			case 'k':
+				arg.WriteString(e.Path)
+				inArg = true
+			case 'i':
+				if e.Icon != "" && !inArg {
+					args = append(args, "--icon", e.Icon)
+				}
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	if quoted {
+		return nil, errors.New("apps: unterminated quote in Exec")
+	}
+	if len(args) == 0 {
+		return nil, errors.New("apps: empty Exec")
+	}
+	return args, nil
+}
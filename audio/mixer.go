@@ -0,0 +1,264 @@
+// Package audio controls the volume of the sound server: the output devices
+// (sinks) and the streams of applications playing to them (sink inputs). It
+// speaks the PulseAudio protocol, which PipeWire provides through
+// pipewire-pulse.
+package audio
+
+import (
+	"net"
+	"sync"
+
+	"github.com/jfreymuth/pulse/proto"
+
+	"github.com/AuruTeam/desktop/watch"
+)
+
+// MaxVolume is the highest volume the mixer sets, 1 being 100%.
+const MaxVolume = 1.5
+
+// Sink is an output device.
+type Sink struct {
+	Index       uint32
+	Name        string
+	Description string
+	// Volume is the loudest channel, 1 being 100%.
+	Volume float64
+	Muted  bool
+
+	// channels are the volumes of the channels, which setting the volume
+	// scales.
+	channels proto.ChannelVolumes
+}
+
+// Stream is the sound of an application playing to a sink.
+type Stream struct {
+	Index uint32
+	// Application is the name of the application, such as "Firefox".
+	Application string
+	// Title describes what is playing, such as the title of a video.
+	Title    string
+	IconName string
+	Sink     uint32
+	Volume   float64
+	Muted    bool
+
+	channels proto.ChannelVolumes
+}
+
+// State is the current state of the sound server.
+type State struct {
+	Sinks   []Sink
+	Streams []Stream
+	// Default is the name of the sink new streams play to.
+	Default string
+}
+
+// DefaultSink returns the sink new streams play to.
+func (s State) DefaultSink() (Sink, bool) {
+	for _, sink := range s.Sinks {
+		if sink.Name == s.Default {
+			return sink, true
+		}
+	}
+	return Sink{}, false
+}
+
+// Mixer is a connection to the sound server.
+type Mixer struct {
+	client *proto.Client
+	conn   net.Conn
+
+	listeners watch.Listeners[struct{}]
+
+	mu         sync.Mutex
+	subscribed bool
+}
+
+// Open connects to the sound server.
+func Open() (*Mixer, error) {
+	client, conn, err := proto.Connect("")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Mixer{client: client, conn: conn}
+	client.Callback = func(msg interface{}) {
+		if _, ok := msg.(*proto.SubscribeEvent); ok {
+			m.listeners.Notify(struct{}{})
+		}
+	}
+
+	err = client.Request(&proto.SetClientName{Props: proto.PropList{
+		"application.name": proto.PropListString("auru-shell"),
+	}}, &proto.SetClientNameReply{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Close disconnects from the sound server.
+func (m *Mixer) Close() error {
+	return m.conn.Close()
+}
+
+// Watch calls changed every time a sink, a stream or the default sink
+// changes, until stop is called. It makes a Mixer a status.Source.
+func (m *Mixer) Watch(changed func()) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.subscribed {
+		mask := proto.SubscriptionMaskSink | proto.SubscriptionMaskSinkInput | proto.SubscriptionMaskServer
+		if err := m.client.Request(&proto.Subscribe{Mask: mask}, nil); err != nil {
+			return nil, err
+		}
+		m.subscribed = true
+	}
+
+	return m.listeners.Add(func(struct{}) { changed() }), nil
+}
+
+// State reads the sinks, the streams and the default sink.
+func (m *Mixer) State() (State, error) {
+	var server proto.GetServerInfoReply
+	if err := m.client.Request(&proto.GetServerInfo{}, &server); err != nil {
+		return State{}, err
+	}
+	var sinks proto.GetSinkInfoListReply
+	if err := m.client.Request(&proto.GetSinkInfoList{}, &sinks); err != nil {
+		return State{}, err
+	}
+	var inputs proto.GetSinkInputInfoListReply
+	if err := m.client.Request(&proto.GetSinkInputInfoList{}, &inputs); err != nil {
+		return State{}, err
+	}
+
+	state := State{Default: server.DefaultSinkName}
+	for _, sink := range sinks {
+		description := property(sink.Properties, "device.description")
+		if description == "" {
+			description = sink.SinkName
+		}
+		state.Sinks = append(state.Sinks, Sink{
+			Index:       sink.SinkIndex,
+			Name:        sink.SinkName,
+			Description: description,
+			Volume:      volume(sink.ChannelVolumes),
+			Muted:       sink.Mute,
+			channels:    sink.ChannelVolumes,
+		})
+	}
+	for _, input := range inputs {
+		// Streams such as event sounds cannot be changed, and are gone
+		// before anyone could anyway.
+		if !input.VolumeWritable && input.VolumeReadable {
+			continue
+		}
+		application := property(input.Properties, "application.name")
+		if application == "" {
+			application = input.MediaName
+		}
+		state.Streams = append(state.Streams, Stream{
+			Index:       input.SinkInputIndex,
+			Application: application,
+			Title:       input.MediaName,
+			IconName:    property(input.Properties, "application.icon_name"),
+			Sink:        input.SinkIndex,
+			Volume:      volume(input.ChannelVolumes),
+			Muted:       input.Muted,
+			channels:    input.ChannelVolumes,
+		})
+	}
+	return state, nil
+}
+
+// SetSinkVolume sets the volume of a sink, keeping the balance between its
+// channels as State last read them.
+func (m *Mixer) SetSinkVolume(sink Sink, v float64) error {
+	return m.client.Request(&proto.SetSinkVolume{
+		SinkIndex:      sink.Index,
+		ChannelVolumes: scale(sink.channels, v),
+	}, nil)
+}
+
+// SetSinkMute mutes or unmutes a sink.
+func (m *Mixer) SetSinkMute(index uint32, muted bool) error {
+	return m.client.Request(&proto.SetSinkMute{SinkIndex: index, Mute: muted}, nil)
+}
+
+// SetDefaultSink makes new streams play to the sink name and moves the
+// playing ones there.
+func (m *Mixer) SetDefaultSink(name string) error {
+	if err := m.client.Request(&proto.SetDefaultSink{SinkName: name}, nil); err != nil {
+		return err
+	}
+
+	var inputs proto.GetSinkInputInfoListReply
+	if err := m.client.Request(&proto.GetSinkInputInfoList{}, &inputs); err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		// Streams that cannot be moved fail; that is fine.
+		m.client.Request(&proto.MoveSinkInput{
+			SinkInputIndex: input.SinkInputIndex,
+			DeviceIndex:    0xFFFFFFFF,
+			DeviceName:     name,
+		}, nil)
+	}
+	return nil
+}
+
+// SetStreamVolume sets the volume of a stream, keeping the balance between
+// its channels as State last read them.
+func (m *Mixer) SetStreamVolume(stream Stream, v float64) error {
+	return m.client.Request(&proto.SetSinkInputVolume{
+		SinkInputIndex: stream.Index,
+		ChannelVolumes: scale(stream.channels, v),
+	}, nil)
+}
+
+// SetStreamMute mutes or unmutes a stream.
+func (m *Mixer) SetStreamMute(index uint32, muted bool) error {
+	return m.client.Request(&proto.SetSinkInputMute{SinkInputIndex: index, Mute: muted}, nil)
+}
+
+func property(props proto.PropList, name string) string {
+	if entry, ok := props[name]; ok {
+		if s := entry.String(); s != "<not a string>" {
+			return s
+		}
+	}
+	return ""
+}
+
+// volume returns the loudest of the channels, as pavucontrol shows it.
+func volume(channels proto.ChannelVolumes) float64 {
+	var loudest uint32
+	for _, v := range channels {
+		loudest = max(loudest, v)
+	}
+	return float64(loudest) / float64(proto.VolumeNorm)
+}
+
+// scale changes the volume of the loudest channel to v and the others in
+// proportion.
+func scale(channels proto.ChannelVolumes, v float64) proto.ChannelVolumes {
+	v = min(max(v, 0), MaxVolume)
+	target := uint32(v * float64(proto.VolumeNorm))
+	if len(channels) == 0 {
+		return proto.ChannelVolumes{target}
+	}
+
+	current := volume(channels) * float64(proto.VolumeNorm)
+	scaled := make(proto.ChannelVolumes, len(channels))
+	for i, c := range channels {
+		if current == 0 {
+			scaled[i] = target
+		} else {
+			scaled[i] = uint32(float64(c) / current * float64(target))
+		}
+	}
+	return scaled
+}
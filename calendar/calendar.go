@@ -0,0 +1,258 @@
+// Package calendar reads events from local iCalendar (.ics) files, such as
+// the ones exported by calendar applications or synchronized from a CalDAV
+// server by vdirsyncer, and expands recurring events.
+package calendar
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Event is an occurrence of a calendar event.
+type Event struct {
+	Summary     string
+	Location    string
+	Description string
+	Start       time.Time
+	// End is exclusive; all-day events end at midnight after their last
+	// day.
+	End    time.Time
+	AllDay bool
+}
+
+// event is an event as defined in a file, possibly recurring.
+type event struct {
+	Event
+	uid  string
+	rule *rule
+	// overrides is set on events that replace an occurrence of a recurring
+	// event.
+	overrides *overrideKey
+	// exclude holds the start times, in Unix seconds, of the occurrences
+	// that were removed or moved.
+	exclude map[int64]bool
+}
+
+// Calendar holds the events of a set of files.
+type Calendar struct {
+	events []*event
+}
+
+// Load reads the .ics files at paths. Directories are searched for .ics
+// files, as vdirsyncer stores one file per event. Files that cannot be
+// read are reported in the error, but do not keep the others from loading.
+func Load(paths ...string) (*Calendar, error) {
+	c := &Calendar{}
+	var errs []error
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(file), ".ics") {
+				return nil
+			}
+			if err := c.loadFile(file); err != nil {
+				errs = append(errs, err)
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.applyOverrides()
+	return c, errors.Join(errs...)
+}
+
+func (c *Calendar) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	components, err := parse(f)
+	if err != nil {
+		return err
+	}
+	for _, comp := range components {
+		if e, ok := newEvent(comp); ok {
+			c.events = append(c.events, e)
+		}
+	}
+	return nil
+}
+
+// overrideKey identifies an occurrence of a recurring event.
+type overrideKey struct {
+	uid   string
+	start int64
+}
+
+func newEvent(comp *component) (*event, bool) {
+	if strings.EqualFold(comp.text("STATUS"), "CANCELLED") {
+		return nil, false
+	}
+	startProp, ok := comp.get("DTSTART")
+	if !ok {
+		return nil, false
+	}
+	start, allDay, err := parseTime(startProp)
+	if err != nil {
+		return nil, false
+	}
+
+	e := &event{Event: Event{
+		Summary:     comp.text("SUMMARY"),
+		Location:    comp.text("LOCATION"),
+		Description: comp.text("DESCRIPTION"),
+		Start:       start,
+		AllDay:      allDay,
+	}, uid: comp.text("UID")}
+
+	if p, ok := comp.get("DTEND"); ok {
+		if end, _, err := parseTime(p); err == nil {
+			e.End = end
+		}
+	} else if p, ok := comp.get("DURATION"); ok {
+		if d, err := parseDuration(p.value); err == nil {
+			e.End = start.Add(d)
+		}
+	}
+	if e.End.Before(start) || e.End.IsZero() {
+		e.End = start
+		if allDay {
+			e.End = start.AddDate(0, 0, 1)
+		}
+	}
+
+	if rrule := comp.text("RRULE"); rrule != "" {
+		e.rule = parseRule(rrule)
+	}
+	for _, p := range comp.all("EXDATE") {
+		for _, value := range strings.Split(p.value, ",") {
+			p.value = value
+			if t, _, err := parseTime(p); err == nil {
+				if e.exclude == nil {
+					e.exclude = make(map[int64]bool)
+				}
+				e.exclude[t.Unix()] = true
+			}
+		}
+	}
+
+	if p, ok := comp.get("RECURRENCE-ID"); ok {
+		if t, _, err := parseTime(p); err == nil {
+			e.overrides = &overrideKey{uid: e.uid, start: t.Unix()}
+		}
+	}
+	return e, true
+}
+
+// applyOverrides removes the occurrences of recurring events that are
+// replaced by an event of their own. It runs once all files are read, as
+// the replacements may come before the recurring event.
+func (c *Calendar) applyOverrides() {
+	recurring := make(map[string][]*event)
+	for _, e := range c.events {
+		if e.rule != nil && e.uid != "" {
+			recurring[e.uid] = append(recurring[e.uid], e)
+		}
+	}
+	for _, e := range c.events {
+		if e.overrides == nil {
+			continue
+		}
+		for _, master := range recurring[e.overrides.uid] {
+			if master.exclude == nil {
+				master.exclude = make(map[int64]bool)
+			}
+			master.exclude[e.overrides.start] = true
+		}
+	}
+}
+
+// Between returns the occurrences of events that overlap the time from
+// from to to, ordered by start. All-day events, which have dates rather
+// than times, are placed on their dates in the time zone of from.
+func (c *Calendar) Between(from, to time.Time) []Event {
+	loc := from.Location()
+	var events []Event
+	for _, e := range c.events {
+		duration := e.End.Sub(e.Start)
+		if e.AllDay {
+			// Keep all-day events on whole days across DST changes.
+			days := int(duration.Hours()+12) / 24
+			duration = time.Duration(days) * 24 * time.Hour
+		}
+		occurrence := func(start time.Time) Event {
+			o := e.Event
+			o.Start = start
+			o.End = start.Add(duration)
+			if e.AllDay {
+				o.Start = onDate(start, loc)
+				o.End = o.Start.AddDate(0, 0, int(duration.Hours())/24)
+			}
+			return o
+		}
+		overlaps := func(o Event) bool {
+			return o.Start.Before(to) && (o.End.After(from) || !o.Start.Before(from))
+		}
+
+		if e.rule == nil {
+			if o := occurrence(e.Start); overlaps(o) {
+				events = append(events, o)
+			}
+			continue
+		}
+
+		// A day more is expanded, as all-day events move with loc.
+		e.rule.each(e.Start, from.Add(-duration-24*time.Hour), to.Add(24*time.Hour), func(start time.Time) {
+			if e.exclude[start.Unix()] {
+				return
+			}
+			if o := occurrence(start); overlaps(o) {
+				events = append(events, o)
+			}
+		})
+	}
+
+	slices.SortStableFunc(events, func(a, b Event) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Summary, b.Summary)
+	})
+	return events
+}
+
+// onDate returns midnight in loc of the date of t in its own time zone.
+func onDate(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// DefaultDir returns the directory calendars are read from when none are
+// configured, $XDG_DATA_HOME/calendars, where vdirsyncer is commonly set
+// up to store the calendars of a CalDAV server.
+func DefaultDir() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "/"
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "calendars")
+}
@@ -0,0 +1,186 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeICS(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	content := "BEGIN:VCALENDAR\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCALENDAR\r\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	dir := t.TempDir()
+	// The moved occurrence comes in a file read before the recurring
+	// event, as vdirsyncer may store them.
+	writeICS(t, dir, "a-moved.ics",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"RECURRENCE-ID:20240103T090000Z",
+		"DTSTART:20240103T140000Z",
+		"DTEND:20240103T141500Z",
+		"SUMMARY:Standup (moved)",
+		"END:VEVENT",
+	)
+	writeICS(t, dir, "b-standup.ics",
+		"BEGIN:VEVENT",
+		"UID:standup",
+		"DTSTART:20240101T090000Z",
+		"DURATION:PT15M",
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"EXDATE:20240102T090000Z,20240104T090000Z",
+		"SUMMARY:Standup",
+		"END:VEVENT",
+	)
+	writeICS(t, dir, "c-other.ics",
+		"BEGIN:VEVENT",
+		"UID:holiday",
+		"DTSTART;VALUE=DATE:20240102",
+		"SUMMARY:Holiday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:cancelled",
+		"STATUS:CANCELLED",
+		"DTSTART:20240101T100000Z",
+		"SUMMARY:Cancelled",
+		"END:VEVENT",
+	)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("SUMMARY:Ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(dir, filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := c.Between(from, from.AddDate(0, 0, 7))
+
+	type occurrence struct {
+		summary string
+		start   time.Time
+		end     time.Time
+	}
+	utc := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+	want := []occurrence{
+		{"Standup", utc(1, 9, 0), utc(1, 9, 15)},
+		// All-day events are on their dates in the time zone of from.
+		{"Holiday", utc(2, 0, 0), utc(3, 0, 0)},
+		{"Standup (moved)", utc(3, 14, 0), utc(3, 14, 15)},
+		{"Standup", utc(5, 9, 0), utc(5, 9, 15)},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Between returned %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, e := range events {
+		w := want[i]
+		if e.Summary != w.summary || !e.Start.Equal(w.start) || !e.End.Equal(w.end) {
+			t.Errorf("event %d = %q %v–%v, want %q %v–%v", i, e.Summary, e.Start, e.End, w.summary, w.start, w.end)
+		}
+	}
+	if i := indexOf(events, "Holiday"); i < 0 || !events[i].AllDay {
+		t.Error("Holiday is missing or not an all-day event")
+	}
+}
+
+func TestBetweenOverlap(t *testing.T) {
+	dir := t.TempDir()
+	writeICS(t, dir, "trip.ics",
+		"BEGIN:VEVENT",
+		"UID:trip",
+		"DTSTART:20240101T220000Z",
+		"DTEND:20240103T080000Z",
+		"SUMMARY:Trip",
+		"END:VEVENT",
+	)
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{day(1), day(2), 1},
+		{day(2), day(3), 1},
+		{day(3), day(4), 1},
+		{day(4), day(5), 0},
+		{day(1).Add(-time.Hour), day(1), 0},
+	}
+	for _, tt := range tests {
+		if got := len(c.Between(tt.from, tt.to)); got != tt.want {
+			t.Errorf("Between(%v, %v) returned %d events, want %d", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestBetweenAllDayInZone(t *testing.T) {
+	dir := t.TempDir()
+	writeICS(t, dir, "days.ics",
+		"BEGIN:VEVENT",
+		"UID:holiday",
+		"DTSTART;VALUE=DATE:20240102",
+		"SUMMARY:Holiday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:weekly",
+		"DTSTART;VALUE=DATE:20240101",
+		"DTEND;VALUE=DATE:20240103",
+		"RRULE:FREQ=WEEKLY",
+		"SUMMARY:Weekly",
+		"END:VEVENT",
+	)
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"America/Los_Angeles", "Asia/Tokyo"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, loc) }
+
+		if got := summaries(c.Between(day(2), day(3))); !slices.Equal(got, []string{"Weekly", "Holiday"}) {
+			t.Errorf("%s: events on January 2nd = %q, want Weekly and Holiday", name, got)
+		}
+		if got := summaries(c.Between(day(3), day(8))); got != nil {
+			t.Errorf("%s: events on January 3rd to 7th = %q, want none", name, got)
+		}
+		events := c.Between(day(8), day(9))
+		if len(events) != 1 || !events[0].Start.Equal(day(8)) || !events[0].End.Equal(day(10)) {
+			t.Errorf("%s: events on January 8th = %+v, want Weekly until the 10th", name, events)
+		}
+	}
+}
+
+func summaries(events []Event) []string {
+	var s []string
+	for _, e := range events {
+		s = append(s, e.Summary)
+	}
+	return s
+}
+
+func indexOf(events []Event, summary string) int {
+	for i, e := range events {
+		if e.Summary == summary {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,242 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// property is a content line of an iCalendar file, such as
+// DTSTART;TZID=Europe/Berlin:20240101T100000.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// component is a BEGIN/END block with its properties. Nested components
+// such as VALARM are skipped.
+type component struct {
+	props []property
+}
+
+func (c *component) get(name string) (property, bool) {
+	for _, p := range c.props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return property{}, false
+}
+
+func (c *component) all(name string) []property {
+	var props []property
+	for _, p := range c.props {
+		if p.name == name {
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+func (c *component) text(name string) string {
+	p, _ := c.get(name)
+	return unescape(p.value)
+}
+
+// parse reads the VEVENT components of an iCalendar stream.
+func parse(r io.Reader) ([]*component, error) {
+	var events []*component
+	var current *component
+	// nested counts the open components inside the current event.
+	nested := 0
+
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		p, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.name == "BEGIN" && current != nil:
+			nested++
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			current = &component{}
+		case p.name == "END" && current != nil:
+			if nested > 0 {
+				nested--
+				continue
+			}
+			events = append(events, current)
+			current = nil
+		case current != nil && nested == 0:
+			current.props = append(current.props, p)
+		}
+	}
+	return events, nil
+}
+
+// unfold joins the lines that continue on the next line, which starts with
+// a space or a tab.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseLine(line string) (property, bool) {
+	// The value starts at the first colon outside of quoted parameters.
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+
+	head := strings.Split(line[:colon], ";")
+	p := property{name: strings.ToUpper(head[0]), value: line[colon+1:]}
+	for _, param := range head[1:] {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		if p.params == nil {
+			p.params = make(map[string]string)
+		}
+		p.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return p, true
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// parseTime reads a DATE or DATE-TIME value. Times without a zone are in
+// the local time zone. All-day dates are read as local midnight; Between
+// moves them to the time zone asked for.
+func parseTime(p property) (t time.Time, allDay bool, err error) {
+	value := p.value
+	if p.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	t, err = time.ParseInLocation("20060102T150405", value, location(p.params["TZID"]))
+	return t, false, err
+}
+
+// location returns the time zone of a TZID parameter, or the local one if
+// it is empty or unknown.
+func location(tzid string) *time.Location {
+	if tzid == "" {
+		return time.Local
+	}
+	candidates := []string{tzid}
+	// Some exporters prefix the zone name with a path, as in
+	// /mozilla.org/20050126_1/Europe/Berlin.
+	parts := strings.Split(strings.Trim(tzid, "/"), "/")
+	if n := len(parts); n >= 2 {
+		candidates = append(candidates, parts[n-2]+"/"+parts[n-1])
+	}
+	for _, name := range candidates {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// parseDuration reads a DURATION value such as PT1H30M or P1D.
+func parseDuration(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("calendar: invalid duration %q", s)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	number := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("calendar: invalid duration %q", s)
+		}
+		number = ""
+		switch {
+		case r == 'W':
+			d += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D':
+			d += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("calendar: invalid duration %q", s)
+		}
+	}
+	return sign * d, nil
+}
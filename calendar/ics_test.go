@@ -0,0 +1,197 @@
+package calendar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestUnfold(t *testing.T) {
+	input := "BEGIN:VEVENT\r\nDESCRIPTION:first \r\n line\r\n\tand tab\r\nEND:VEVENT\r\n"
+	want := []string{"BEGIN:VEVENT", "DESCRIPTION:first line" + "and tab", "END:VEVENT"}
+
+	got, err := unfold(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unfold = %q, want %q", got, want)
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want property
+		ok   bool
+	}{
+		{"SUMMARY:Lunch", property{name: "SUMMARY", value: "Lunch"}, true},
+		{"summary:Lunch", property{name: "SUMMARY", value: "Lunch"}, true},
+		{"LOCATION:Room 1: east", property{name: "LOCATION", value: "Room 1: east"}, true},
+		{
+			"DTSTART;TZID=Europe/Berlin:20240101T100000",
+			property{name: "DTSTART", params: map[string]string{"TZID": "Europe/Berlin"}, value: "20240101T100000"},
+			true,
+		},
+		{
+			`ATTENDEE;CN="Doe: John";role=CHAIR:mailto:john@example.com`,
+			property{name: "ATTENDEE", params: map[string]string{"CN": "Doe: John", "ROLE": "CHAIR"}, value: "mailto:john@example.com"},
+			true,
+		},
+		{"no colon", property{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLine(tt.line)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLine(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain", "plain"},
+		{`a\, b\; c`, "a, b; c"},
+		{`one\ntwo\Nthree`, "one\ntwo\nthree"},
+		{`back\\slash`, `back\slash`},
+		{`trailing\`, `trailing\`},
+	}
+	for _, tt := range tests {
+		if got := unescape(tt.in); got != tt.want {
+			t.Errorf("unescape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		p      property
+		want   time.Time
+		allDay bool
+	}{
+		{
+			"utc",
+			property{value: "20240301T090000Z"},
+			time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			false,
+		},
+		{
+			"tzid",
+			property{params: map[string]string{"TZID": "Europe/Berlin"}, value: "20240301T090000"},
+			time.Date(2024, 3, 1, 9, 0, 0, 0, berlin),
+			false,
+		},
+		{
+			"tzid with path prefix",
+			property{params: map[string]string{"TZID": "/mozilla.org/20050126_1/Europe/Berlin"}, value: "20240301T090000"},
+			time.Date(2024, 3, 1, 9, 0, 0, 0, berlin),
+			false,
+		},
+		{
+			"date",
+			property{params: map[string]string{"VALUE": "DATE"}, value: "20240229"},
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local),
+			true,
+		},
+		{
+			"date without value parameter",
+			property{value: "20240229"},
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local),
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, allDay, err := parseTime(tt.p)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !got.Equal(tt.want) || allDay != tt.allDay {
+			t.Errorf("%s: parseTime = %v, %v, want %v, %v", tt.name, got, allDay, tt.want, tt.allDay)
+		}
+	}
+
+	if _, _, err := parseTime(property{value: "2024-03-01"}); err == nil {
+		t.Error("parseTime accepted an invalid date")
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct{ tzid, want string }{
+		{"", time.Local.String()},
+		{"Europe/Berlin", "Europe/Berlin"},
+		{"/citadel.org/20190914_1/America/New_York", "America/New_York"},
+		{"Not/A_Zone", time.Local.String()},
+	}
+	for _, tt := range tests {
+		if got := location(tt.tzid).String(); got != tt.want {
+			t.Errorf("location(%q) = %s, want %s", tt.tzid, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"PT1H30M", 90 * time.Minute, true},
+		{"P1D", 24 * time.Hour, true},
+		{"P2W", 14 * 24 * time.Hour, true},
+		{"P1DT12H", 36 * time.Hour, true},
+		{"PT45S", 45 * time.Second, true},
+		{"+PT15M", 15 * time.Minute, true},
+		{"-PT15M", -15 * time.Minute, true},
+		{"P", 0, true},
+		{"1H", 0, false},
+		{"P1M", 0, false},
+		{"PT1X", 0, false},
+		{"PTH", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseDuration(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, %v, want %v, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:Standup",
+		"BEGIN:VALARM",
+		"SUMMARY:Alarm",
+		"END:VALARM",
+		"UID:1",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"SUMMARY:Not an event",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("parse returned %d events, want 1", len(events))
+	}
+	if got := events[0].text("SUMMARY"); got != "Standup" {
+		t.Errorf("SUMMARY = %q, want the one of the event rather than the alarm", got)
+	}
+	if got := events[0].text("UID"); got != "1" {
+		t.Errorf("UID = %q, want the one after the alarm", got)
+	}
+}
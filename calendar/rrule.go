@@ -0,0 +1,222 @@
+package calendar
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPeriods bounds the expansion of rules that yield no occurrence, such
+// as the 31st of every February, and of long ranges.
+const maxPeriods = 10000
+
+// rule is a recurrence rule (RRULE). Only the parts in common use are
+// supported: FREQ, INTERVAL, COUNT, UNTIL, and BYDAY for weekly and monthly
+// events.
+type rule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []weekday
+}
+
+// weekday is a BYDAY entry such as MO, or 2TU and -1FR for the second
+// Tuesday and the last Friday of a month.
+type weekday struct {
+	n   int
+	day time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// parseRule reads an RRULE value. It returns nil for rules it cannot
+// expand, so that only the first occurrence is shown.
+func parseRule(s string) *rule {
+	r := &rule{interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		name, value, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			r.freq = strings.ToUpper(value)
+		case "INTERVAL":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				r.interval = n
+			}
+		case "COUNT":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				r.count = n
+			}
+		case "UNTIL":
+			if t, _, err := parseTime(property{value: value}); err == nil {
+				r.until = t
+			}
+		case "BYDAY":
+			for _, entry := range strings.Split(strings.ToUpper(value), ",") {
+				if len(entry) < 2 {
+					return nil
+				}
+				day, ok := weekdays[entry[len(entry)-2:]]
+				if !ok {
+					return nil
+				}
+				n := 0
+				if prefix := entry[:len(entry)-2]; prefix != "" {
+					var err error
+					if n, err = strconv.Atoi(prefix); err != nil {
+						return nil
+					}
+				}
+				r.byDay = append(r.byDay, weekday{n: n, day: day})
+			}
+		case "BYMONTHDAY", "BYMONTH", "BYSETPOS", "BYYEARDAY", "BYWEEKNO", "BYHOUR", "BYMINUTE", "BYSECOND":
+			return nil
+		}
+	}
+
+	switch r.freq {
+	case "DAILY", "YEARLY":
+		if r.byDay != nil {
+			return nil
+		}
+	case "WEEKLY":
+		for _, d := range r.byDay {
+			if d.n != 0 {
+				return nil
+			}
+		}
+	case "MONTHLY":
+	default:
+		return nil
+	}
+	return r
+}
+
+// each calls fn with the start of every occurrence of an event that starts
+// at start, in order, from about from until to. Occurrences a little before
+// from may be included. Rules with a COUNT are expanded from start, as the
+// count includes the occurrences before from.
+func (r *rule) each(start, from, to time.Time, fn func(time.Time)) {
+	first := 0
+	if r.count == 0 {
+		// Start a period early, as the estimate may be one too high
+		// around DST changes and the periods of BYDAY rules overlap.
+		first = max(r.periodsBefore(start, from)/r.interval-1, 0)
+	}
+
+	count := 0
+	for period := first; period < first+maxPeriods; period++ {
+		for _, t := range r.period(start, period*r.interval) {
+			if t.Before(start) {
+				continue
+			}
+			if !t.Before(to) || (!r.until.IsZero() && t.After(r.until)) {
+				return
+			}
+			fn(t)
+			count++
+			if r.count > 0 && count >= r.count {
+				return
+			}
+		}
+	}
+}
+
+// periodsBefore estimates how many days, weeks, months or years after the
+// one of start t is.
+func (r *rule) periodsBefore(start, t time.Time) int {
+	if !t.After(start) {
+		return 0
+	}
+	switch r.freq {
+	case "DAILY":
+		return int(t.Sub(start).Hours() / 24)
+	case "WEEKLY":
+		return int(t.Sub(start).Hours() / (24 * 7))
+	case "MONTHLY":
+		return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	case "YEARLY":
+		return t.Year() - start.Year()
+	}
+	return 0
+}
+
+// period returns the occurrences in the nth day, week, month or year after
+// the one of start.
+func (r *rule) period(start time.Time, n int) []time.Time {
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, start.Location())
+	}
+
+	switch r.freq {
+	case "DAILY":
+		return []time.Time{at(year, month, day+n)}
+
+	case "WEEKLY":
+		if r.byDay == nil {
+			return []time.Time{at(year, month, day+7*n)}
+		}
+		// Weeks start on Monday.
+		monday := day - (int(start.Weekday())+6)%7 + 7*n
+		var times []time.Time
+		for _, d := range r.byDay {
+			times = append(times, at(year, month, monday+(int(d.day)+6)%7))
+		}
+		slices.SortFunc(times, time.Time.Compare)
+		return times
+
+	case "MONTHLY":
+		first := at(year, month+time.Month(n), 1)
+		if r.byDay == nil {
+			if t := at(first.Year(), first.Month(), day); t.Day() == day {
+				return []time.Time{t}
+			}
+			return nil
+		}
+		var times []time.Time
+		for _, d := range r.byDay {
+			times = append(times, weekdaysInMonth(first, d)...)
+		}
+		slices.SortFunc(times, time.Time.Compare)
+		return slices.CompactFunc(times, time.Time.Equal)
+
+	case "YEARLY":
+		if t := at(year+n, month, day); t.Day() == day {
+			return []time.Time{t}
+		}
+	}
+	return nil
+}
+
+// weekdaysInMonth returns the days of the month starting at first that
+// match d: all of them, or only the nth from the start or the end.
+func weekdaysInMonth(first time.Time, d weekday) []time.Time {
+	year, month, _ := first.Date()
+	hour, min, sec := first.Clock()
+	days := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var times []time.Time
+	for day := 1 + (int(d.day)-int(first.Weekday())+7)%7; day <= days; day += 7 {
+		times = append(times, time.Date(year, month, day, hour, min, sec, 0, first.Location()))
+	}
+	switch {
+	case d.n > 0 && d.n <= len(times):
+		return times[d.n-1 : d.n]
+	case d.n < 0 && -d.n <= len(times):
+		return times[len(times)+d.n : len(times)+d.n+1]
+	case d.n != 0:
+		return nil
+	}
+	return times
+}
@@ -0,0 +1,216 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"FREQ=DAILY", true},
+		{"FREQ=WEEKLY;BYDAY=MO,WE,FR", true},
+		{"FREQ=MONTHLY;BYDAY=2TU,-1FR", true},
+		{"freq=yearly;interval=2", true},
+		{"FREQ=WEEKLY;BYDAY=1MO", false},
+		{"FREQ=DAILY;BYDAY=MO", false},
+		{"FREQ=MONTHLY;BYDAY=XX", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=15", false},
+		{"FREQ=HOURLY", false},
+		{"INTERVAL=2", false},
+	}
+	for _, tt := range tests {
+		if got := parseRule(tt.in) != nil; got != tt.want {
+			t.Errorf("parseRule(%q) != nil is %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	r := parseRule("FREQ=WEEKLY;INTERVAL=0;COUNT=3;UNTIL=20240601T000000Z;BYDAY=TU")
+	if r.interval != 1 || r.count != 3 || !r.until.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseRule = %+v", r)
+	}
+	if len(r.byDay) != 1 || r.byDay[0] != (weekday{day: time.Tuesday}) {
+		t.Errorf("BYDAY = %v, want TU", r.byDay)
+	}
+}
+
+func TestEach(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		to    time.Time
+		want  []time.Time
+	}{
+		{
+			"daily with count",
+			"FREQ=DAILY;COUNT=3",
+			utc(2024, 1, 30, 9), utc(2025, 1, 1, 0),
+			[]time.Time{utc(2024, 1, 30, 9), utc(2024, 1, 31, 9), utc(2024, 2, 1, 9)},
+		},
+		{
+			"daily until, inclusive",
+			"FREQ=DAILY;UNTIL=20240103T090000Z",
+			utc(2024, 1, 1, 9), utc(2025, 1, 1, 0),
+			[]time.Time{utc(2024, 1, 1, 9), utc(2024, 1, 2, 9), utc(2024, 1, 3, 9)},
+		},
+		{
+			"every other day up to the end of the range",
+			"FREQ=DAILY;INTERVAL=2",
+			utc(2024, 1, 1, 9), utc(2024, 1, 6, 0),
+			[]time.Time{utc(2024, 1, 1, 9), utc(2024, 1, 3, 9), utc(2024, 1, 5, 9)},
+		},
+		{
+			// 2024-01-03 is a Wednesday; the Monday of its week is
+			// before the start and skipped.
+			"weekly by day",
+			"FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4",
+			utc(2024, 1, 3, 9), utc(2025, 1, 1, 0),
+			[]time.Time{utc(2024, 1, 3, 9), utc(2024, 1, 5, 9), utc(2024, 1, 8, 9), utc(2024, 1, 10, 9)},
+		},
+		{
+			"biweekly",
+			"FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			utc(2024, 1, 1, 9), utc(2025, 1, 1, 0),
+			[]time.Time{utc(2024, 1, 1, 9), utc(2024, 1, 15, 9), utc(2024, 1, 29, 9)},
+		},
+		{
+			"monthly on the 31st skips shorter months",
+			"FREQ=MONTHLY;COUNT=3",
+			utc(2024, 1, 31, 9), utc(2025, 1, 1, 0),
+			[]time.Time{utc(2024, 1, 31, 9), utc(2024, 3, 31, 9), utc(2024, 5, 31, 9)},
+		},
+		{
+			"second Tuesday and last Friday",
+			"FREQ=MONTHLY;BYDAY=2TU,-1FR;COUNT=4",
+			utc(2024, 1, 9, 9), utc(2025, 1, 1, 0),
+			[]time.Time{utc(2024, 1, 9, 9), utc(2024, 1, 26, 9), utc(2024, 2, 13, 9), utc(2024, 2, 23, 9)},
+		},
+		{
+			"fifth Monday only in months that have one",
+			"FREQ=MONTHLY;BYDAY=5MO;COUNT=2",
+			utc(2024, 1, 29, 9), utc(2025, 1, 1, 0),
+			[]time.Time{utc(2024, 1, 29, 9), utc(2024, 4, 29, 9)},
+		},
+		{
+			"February 29th only in leap years",
+			"FREQ=YEARLY;COUNT=2",
+			utc(2024, 2, 29, 0), utc(2040, 1, 1, 0),
+			[]time.Time{utc(2024, 2, 29, 0), utc(2028, 2, 29, 0)},
+		},
+		{
+			"never matching rule ends",
+			"FREQ=MONTHLY;BYDAY=6MO",
+			utc(2024, 1, 1, 9), utc(9999, 1, 1, 0),
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		r := parseRule(tt.rule)
+		if r == nil {
+			t.Errorf("%s: parseRule(%q) = nil", tt.name, tt.rule)
+			continue
+		}
+		var got []time.Time
+		r.each(tt.start, tt.start, tt.to, func(start time.Time) { got = append(got, start) })
+		if !equalTimes(got, tt.want) {
+			t.Errorf("%s: each = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEachFrom(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name            string
+		rule            string
+		start, from, to time.Time
+		want            []time.Time
+	}{
+		{
+			// Far more days than maxPeriods after the start.
+			"daily since long ago",
+			"FREQ=DAILY",
+			utc(1970, 1, 1, 9), utc(2024, 3, 1, 0), utc(2024, 3, 3, 0),
+			[]time.Time{utc(2024, 3, 1, 9), utc(2024, 3, 2, 9)},
+		},
+		{
+			"every third day keeps its phase",
+			"FREQ=DAILY;INTERVAL=3",
+			utc(2000, 1, 1, 9), utc(2024, 1, 1, 0), utc(2024, 1, 7, 0),
+			[]time.Time{utc(2024, 1, 1, 9), utc(2024, 1, 4, 9)},
+		},
+		{
+			"weekly by day",
+			"FREQ=WEEKLY;BYDAY=MO,FR",
+			utc(1950, 1, 2, 9), utc(2024, 1, 8, 0), utc(2024, 1, 13, 0),
+			[]time.Time{utc(2024, 1, 8, 9), utc(2024, 1, 12, 9)},
+		},
+		{
+			"last Friday of the month",
+			"FREQ=MONTHLY;BYDAY=-1FR",
+			utc(1900, 1, 26, 9), utc(2024, 2, 1, 0), utc(2024, 3, 1, 0),
+			[]time.Time{utc(2024, 2, 23, 9)},
+		},
+		{
+			"count includes the occurrences before from",
+			"FREQ=DAILY;COUNT=3",
+			utc(2024, 1, 1, 9), utc(2024, 1, 2, 0), utc(2024, 2, 1, 0),
+			[]time.Time{utc(2024, 1, 2, 9), utc(2024, 1, 3, 9)},
+		},
+	}
+	for _, tt := range tests {
+		var got []time.Time
+		parseRule(tt.rule).each(tt.start, tt.from, tt.to, func(start time.Time) {
+			// Occurrences a little before from are allowed.
+			if !start.Before(tt.from) {
+				got = append(got, start)
+			}
+		})
+		if !equalTimes(got, tt.want) {
+			t.Errorf("%s: each = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEachKeepsLocalTimeAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Summer time starts on 2024-03-31.
+	start := time.Date(2024, 3, 30, 10, 0, 0, 0, berlin)
+	var got []time.Time
+	parseRule("FREQ=DAILY;COUNT=3").each(start, start, start.AddDate(0, 1, 0), func(t time.Time) {
+		got = append(got, t)
+	})
+	for _, occurrence := range got {
+		if occurrence.Hour() != 10 {
+			t.Errorf("occurrence at %v, want 10:00 local time", occurrence)
+		}
+	}
+	if len(got) != 3 || got[1].Sub(got[0]) != 23*time.Hour {
+		t.Errorf("each = %v, want three days with a 23 hour one", got)
+	}
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
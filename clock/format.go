@@ -0,0 +1,142 @@
+// Package clock formats times with strftime-like layouts and day and month
+// names in the language of the user.
+package clock
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format formats t according to layout, which uses the conversions of
+// strftime: %a %A %b %h %B %c %C %d %D %e %F %G %H %I %j %k %l %m %M %n %p
+// %P %r %R %S %t %T %u %V %w %y %Y %z %Z and %%. As with glibc, %OB is
+// the month name on its own, for languages that decline it after a day,
+// and a - after the % drops the padding of numbers. Unknown conversions
+// are kept as they are.
+func (l Locale) Format(t time.Time, layout string) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' || i == len(layout)-1 {
+			b.WriteByte(layout[i])
+			continue
+		}
+
+		start := i
+		i++
+		pad := true
+		if layout[i] == '-' && i < len(layout)-1 {
+			pad = false
+			i++
+		}
+		standalone := false
+		if layout[i] == 'O' && i < len(layout)-1 {
+			standalone = true
+			i++
+		}
+
+		if s, ok := l.conversion(t, layout[i], pad, standalone); ok {
+			b.WriteString(s)
+		} else {
+			b.WriteString(layout[start : i+1])
+		}
+	}
+	return b.String()
+}
+
+func (l Locale) conversion(t time.Time, c byte, pad, standalone bool) (string, bool) {
+	number := func(n, width int, padding byte) string {
+		s := strconv.Itoa(n)
+		if pad && len(s) < width {
+			s = strings.Repeat(string(padding), width-len(s)) + s
+		}
+		return s
+	}
+	hour12 := t.Hour() % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+
+	switch c {
+	case 'a':
+		return l.ShortDays[t.Weekday()], true
+	case 'A':
+		return l.Days[t.Weekday()], true
+	case 'b', 'h':
+		return l.ShortMonths[t.Month()-1], true
+	case 'B':
+		if standalone && l.StandaloneMonths[t.Month()-1] != "" {
+			return l.StandaloneMonths[t.Month()-1], true
+		}
+		return l.Months[t.Month()-1], true
+	case 'c':
+		return l.Format(t, "%a %d %b %Y %H:%M:%S"), true
+	case 'C':
+		return number(t.Year()/100, 2, '0'), true
+	case 'd':
+		return number(t.Day(), 2, '0'), true
+	case 'D':
+		return l.Format(t, "%m/%d/%y"), true
+	case 'e':
+		return number(t.Day(), 2, ' '), true
+	case 'F':
+		return l.Format(t, "%Y-%m-%d"), true
+	case 'G':
+		year, _ := t.ISOWeek()
+		return strconv.Itoa(year), true
+	case 'H':
+		return number(t.Hour(), 2, '0'), true
+	case 'I':
+		return number(hour12, 2, '0'), true
+	case 'j':
+		return number(t.YearDay(), 3, '0'), true
+	case 'k':
+		return number(t.Hour(), 2, ' '), true
+	case 'l':
+		return number(hour12, 2, ' '), true
+	case 'm':
+		return number(int(t.Month()), 2, '0'), true
+	case 'M':
+		return number(t.Minute(), 2, '0'), true
+	case 'n':
+		return "\n", true
+	case 'p':
+		if t.Hour() < 12 {
+			return l.AM, true
+		}
+		return l.PM, true
+	case 'P':
+		if t.Hour() < 12 {
+			return strings.ToLower(l.AM), true
+		}
+		return strings.ToLower(l.PM), true
+	case 'r':
+		return l.Format(t, "%I:%M:%S %p"), true
+	case 'R':
+		return l.Format(t, "%H:%M"), true
+	case 'S':
+		return number(t.Second(), 2, '0'), true
+	case 't':
+		return "\t", true
+	case 'T':
+		return l.Format(t, "%H:%M:%S"), true
+	case 'u':
+		return strconv.Itoa((int(t.Weekday())+6)%7 + 1), true
+	case 'V':
+		_, week := t.ISOWeek()
+		return number(week, 2, '0'), true
+	case 'w':
+		return strconv.Itoa(int(t.Weekday())), true
+	case 'y':
+		return number(t.Year()%100, 2, '0'), true
+	case 'Y':
+		return strconv.Itoa(t.Year()), true
+	case 'z':
+		return t.Format("-0700"), true
+	case 'Z':
+		return t.Format("MST"), true
+	case '%':
+		return "%", true
+	}
+	return "", false
+}
@@ -0,0 +1,102 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	// A Tuesday morning.
+	morning := time.Date(2024, 3, 5, 7, 4, 9, 0, time.UTC)
+	// Belongs to the first ISO week of 2025.
+	evening := time.Date(2024, 12, 30, 19, 30, 0, 0, time.FixedZone("CET", 3600))
+	midnight := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		t      time.Time
+		layout string
+		want   string
+	}{
+		{morning, "%a %A %b %h %B", "Tue Tuesday Mar Mar March"},
+		{morning, "%c", "Tue 05 Mar 2024 07:04:09"},
+		{morning, "%C %y %Y", "20 24 2024"},
+		{morning, "%d %e %m %j", "05  5 03 065"},
+		{morning, "%D %F", "03/05/24 2024-03-05"},
+		{morning, "%H %k %I %l %M %S", "07  7 07  7 04 09"},
+		{morning, "%p %P", "AM am"},
+		{evening, "%p %P %I %l", "PM pm 07  7"},
+		{midnight, "%H %I %p", "00 12 AM"},
+		{morning, "%r %R %T", "07:04:09 AM 07:04 07:04:09"},
+		{morning, "%u %w", "2 2"},
+		{time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), "%u %w", "7 0"},
+		{evening, "%G-W%V", "2025-W01"},
+		{evening, "%z %Z", "+0100 CET"},
+		{morning, "a%nb%tc%%d", "a\nb\tc%d"},
+
+		// The - flag drops the padding of numbers only.
+		{morning, "%-d %-e %-m %-H %-I %-j", "5 5 3 7 7 65"},
+		{morning, "%-y %-M %-S", "24 4 9"},
+		{morning, "%-b %-Y", "Mar 2024"},
+
+		// %OB is the month on its own, which English does not decline.
+		{morning, "%OB %-OB", "March March"},
+
+		// Unknown conversions and modifiers without a conversion are
+		// kept as they are.
+		{morning, "%q %-q %Oq", "%q %-q %Oq"},
+		{morning, "100%", "100%"},
+		{morning, "%H%", "07%"},
+		{morning, "%H%-", "07%-"},
+		{morning, "%H%O", "07%O"},
+		{morning, "%H%-O", "07%-O"},
+		{morning, "", ""},
+	}
+	for _, tt := range tests {
+		if got := English.Format(tt.t, tt.layout); got != tt.want {
+			t.Errorf("Format(%v, %q) = %q, want %q", tt.t, tt.layout, got, tt.want)
+		}
+	}
+}
+
+func TestFormatStandaloneMonths(t *testing.T) {
+	ru := LookupLocale("ru_RU.UTF-8")
+	march := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct{ layout, want string }{
+		{"%-d %B", "5 марта"},
+		{"%OB %Y", "Март 2024"},
+		{"%a %b", "Вт мар"},
+	}
+	for _, tt := range tests {
+		if got := ru.Format(march, tt.layout); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.layout, got, tt.want)
+		}
+	}
+
+	// Languages without declined months fall back to Months.
+	if got := LookupLocale("de_DE").Format(march, "%OB"); got != "März" {
+		t.Errorf("Format(%%OB) in German = %q, want März", got)
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	tests := []struct {
+		name   string
+		today  string
+		hour12 bool
+	}{
+		{"en_US.UTF-8", "Today", true},
+		{"en_GB.UTF-8", "Today", false},
+		{"de_DE@euro", "Heute", false},
+		{"fr_CA", "Aujourd’hui", true},
+		// Untranslated languages get English.
+		{"ja_JP.UTF-8", "Today", false},
+		{"C", "Today", false},
+	}
+	for _, tt := range tests {
+		l := LookupLocale(tt.name)
+		if l.Today != tt.today || l.Hour12 != tt.hour12 {
+			t.Errorf("LookupLocale(%q) = %q, Hour12 %v, want %q, Hour12 %v", tt.name, l.Today, l.Hour12, tt.today, tt.hour12)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package clock
+
+import (
+	"os"
+	"strings"
+)
+
+// Locale holds the names Format writes for days, months and the time of
+// day, and the few words the calendar shows. Days start on Sunday, as
+// time.Weekday does.
+type Locale struct {
+	Days        [7]string
+	ShortDays   [7]string
+	Months      [12]string
+	ShortMonths [12]string
+	// StandaloneMonths are the month names without a day, for languages
+	// where Months are declined. They are empty otherwise.
+	StandaloneMonths [12]string
+	AM, PM           string
+
+	Today, Tomorrow string
+	// AllDay marks events without a time, NoEvents an empty list of them.
+	AllDay, NoEvents string
+
+	// Hour12 is set for regions that use the 12-hour clock.
+	Hour12 bool
+}
+
+// English is the locale used when no other one matches.
+var English = Locale{
+	Days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	ShortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	AM:          "AM",
+	PM:          "PM",
+	Today:       "Today",
+	Tomorrow:    "Tomorrow",
+	AllDay:      "All day",
+	NoEvents:    "No upcoming events",
+}
+
+// locales are the translations, by language code.
+var locales = map[string]Locale{
+	"en": English,
+	"de": {
+		Days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		ShortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		AM:          "AM",
+		PM:          "PM",
+		Today:       "Heute",
+		Tomorrow:    "Morgen",
+		AllDay:      "Ganztägig",
+		NoEvents:    "Keine anstehenden Termine",
+	},
+	"es": {
+		Days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		ShortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		Months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		ShortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		AM:          "a. m.",
+		PM:          "p. m.",
+		Today:       "Hoy",
+		Tomorrow:    "Mañana",
+		AllDay:      "Todo el día",
+		NoEvents:    "No hay eventos próximos",
+	},
+	"fr": {
+		Days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		ShortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		Months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		ShortMonths: [12]string{"janv.", "févr.", "mars", "avril", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		AM:          "AM",
+		PM:          "PM",
+		Today:       "Aujourd’hui",
+		Tomorrow:    "Demain",
+		AllDay:      "Toute la journée",
+		NoEvents:    "Aucun événement à venir",
+	},
+	"it": {
+		Days:        [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		ShortDays:   [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		Months:      [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		ShortMonths: [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		AM:          "AM",
+		PM:          "PM",
+		Today:       "Oggi",
+		Tomorrow:    "Domani",
+		AllDay:      "Tutto il giorno",
+		NoEvents:    "Nessun evento in programma",
+	},
+	"pl": {
+		Days:             [7]string{"niedziela", "poniedziałek", "wtorek", "środa", "czwartek", "piątek", "sobota"},
+		ShortDays:        [7]string{"nie", "pon", "wto", "śro", "czw", "pią", "sob"},
+		Months:           [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"},
+		ShortMonths:      [12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		StandaloneMonths: [12]string{"styczeń", "luty", "marzec", "kwiecień", "maj", "czerwiec", "lipiec", "sierpień", "wrzesień", "październik", "listopad", "grudzień"},
+		AM:               "AM",
+		PM:               "PM",
+		Today:            "Dzisiaj",
+		Tomorrow:         "Jutro",
+		AllDay:           "Cały dzień",
+		NoEvents:         "Brak nadchodzących wydarzeń",
+	},
+	"pt": {
+		Days:        [7]string{"domingo", "segunda", "terça", "quarta", "quinta", "sexta", "sábado"},
+		ShortDays:   [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+		Months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		ShortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		AM:          "AM",
+		PM:          "PM",
+		Today:       "Hoje",
+		Tomorrow:    "Amanhã",
+		AllDay:      "Dia inteiro",
+		NoEvents:    "Nenhum evento próximo",
+	},
+	"ru": {
+		Days:             [7]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"},
+		ShortDays:        [7]string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"},
+		Months:           [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+		ShortMonths:      [12]string{"янв", "фев", "мар", "апр", "мая", "июн", "июл", "авг", "сен", "окт", "ноя", "дек"},
+		StandaloneMonths: [12]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", "Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"},
+		AM:               "AM",
+		PM:               "PM",
+		Today:            "Сегодня",
+		Tomorrow:         "Завтра",
+		AllDay:           "Весь день",
+		NoEvents:         "Нет предстоящих событий",
+	},
+	"uk": {
+		Days:             [7]string{"неділя", "понеділок", "вівторок", "середа", "четвер", "пʼятниця", "субота"},
+		ShortDays:        [7]string{"нд", "пн", "вт", "ср", "чт", "пт", "сб"},
+		Months:           [12]string{"січня", "лютого", "березня", "квітня", "травня", "червня", "липня", "серпня", "вересня", "жовтня", "листопада", "грудня"},
+		ShortMonths:      [12]string{"січ", "лют", "бер", "кві", "тра", "чер", "лип", "сер", "вер", "жов", "лис", "гру"},
+		StandaloneMonths: [12]string{"січень", "лютий", "березень", "квітень", "травень", "червень", "липень", "серпень", "вересень", "жовтень", "листопад", "грудень"},
+		AM:               "AM",
+		PM:               "PM",
+		Today:            "Сьогодні",
+		Tomorrow:         "Завтра",
+		AllDay:           "Увесь день",
+		NoEvents:         "Немає запланованих подій",
+	},
+}
+
+// hour12Regions are the regions that use the 12-hour clock.
+var hour12Regions = map[string]bool{
+	"US": true, "CA": true, "AU": true, "NZ": true, "IN": true,
+	"PH": true, "PK": true, "EG": true, "SA": true,
+}
+
+// LookupLocale returns the locale of a POSIX locale name such as
+// ru_RU.UTF-8. An empty name stands for the locale of the environment, as
+// set by LC_ALL, LC_TIME or LANG. Only English, German, Spanish, French,
+// Italian, Polish, Portuguese, Russian and Ukrainian are translated; other
+// languages get English. Hour12 follows the region of the name.
+func LookupLocale(name string) Locale {
+	if name == "" {
+		for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+			if name = os.Getenv(env); name != "" {
+				break
+			}
+		}
+	}
+	language, _, _ := strings.Cut(name, ".")
+	language, _, _ = strings.Cut(language, "@")
+	language, region, _ := strings.Cut(language, "_")
+
+	l, ok := locales[strings.ToLower(language)]
+	if !ok {
+		l = English
+	}
+	l.Hour12 = hour12Regions[strings.ToUpper(region)]
+	return l
+}
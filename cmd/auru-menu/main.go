@@ -0,0 +1,22 @@
+// Command auru-menu shows the Auru main menu as a standalone window.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/AuruTeam/desktop/shell"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+func main() {
+	gtk.Init(&os.Args)
+	if err := shell.LoadCSS(shell.DefaultCSSPath); err != nil {
+		log.Println("Failed to load CSS into GTK:", err)
+	}
+
+	win := shell.CreateMainMenu(nil)
+	win.Connect("destroy", gtk.MainQuit)
+	win.ShowAll()
+	gtk.Main()
+}
@@ -0,0 +1,33 @@
+// Command auru-shell runs the Auru desktop shell: the main bars together with
+// the notification daemon.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/AuruTeam/desktop/shell"
+	"github.com/AuruTeam/desktoplib/wallpaper"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+func main() {
+	wallpaper.SetImageWallpaper("/usr/share/backgrounds/auruos_dark_default.jpg", "")
+
+	gtk.Init(&os.Args)
+	if err := shell.LoadCSS(shell.DefaultCSSPath); err != nil {
+		log.Println("Failed to load CSS into GTK:", err)
+	}
+
+	daemon, err := shell.ListenNotifications()
+	if err != nil {
+		log.Fatalf("Failed to start notification daemon: %v", err)
+	}
+	defer daemon.Stop()
+
+	shell.ShowBars(daemon)
+	shell.ShowNotificationPopups(daemon, nil)
+	shell.WatchBattery()
+
+	gtk.Main()
+}
@@ -0,0 +1,29 @@
+// Package compositor talks to the IPC of the running compositor, sway or
+// Hyprland, for what the Wayland protocols used by the shell cannot do.
+package compositor
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrUnsupported is returned by Detect when no known compositor is running.
+var ErrUnsupported = errors.New("compositor: no supported compositor IPC found")
+
+// Compositor runs window management commands.
+type Compositor interface {
+	// MoveToOutput moves the focused window to the output with the given
+	// name, such as "DP-1".
+	MoveToOutput(output string) error
+}
+
+// Detect returns the IPC of the running compositor.
+func Detect() (Compositor, error) {
+	if socket := os.Getenv("SWAYSOCK"); socket != "" {
+		return Sway(socket), nil
+	}
+	if signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"); signature != "" {
+		return Hyprland(signature), nil
+	}
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,78 @@
+package compositor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HyprlandIPC is the pair of IPC sockets of a Hyprland instance.
+type HyprlandIPC struct {
+	dir string
+}
+
+// Hyprland returns the IPC of the Hyprland instance with the given
+// signature.
+func Hyprland(signature string) HyprlandIPC {
+	// Hyprland moved its sockets from /tmp to the runtime directory.
+	dir := filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "hypr", signature)
+	if _, err := os.Stat(dir); err != nil {
+		dir = filepath.Join("/tmp/hypr", signature)
+	}
+	return HyprlandIPC{dir: dir}
+}
+
+// Call sends a request, such as "j/devices", and returns the reply.
+func (h HyprlandIPC) Call(request string) ([]byte, error) {
+	conn, err := net.Dial("unix", filepath.Join(h.dir, ".socket.sock"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, request); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(conn)
+}
+
+// Command sends a request that is answered with "ok" on success, such as
+// a dispatch.
+func (h HyprlandIPC) Command(request string) error {
+	data, err := h.Call(request)
+	if err != nil {
+		return err
+	}
+	if reply := strings.TrimSpace(string(data)); reply != "ok" {
+		return fmt.Errorf("compositor: Hyprland: %s", reply)
+	}
+	return nil
+}
+
+// Listen calls handle from a background goroutine with the name and data of
+// every event, such as "activelayout" and "keyboard,English (US)". The
+// returned function stops listening.
+func (h HyprlandIPC) Listen(handle func(event, data string)) (func(), error) {
+	conn, err := net.Dial("unix", filepath.Join(h.dir, ".socket2.sock"))
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		// Scanning stops once the connection is closed.
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			event, data, _ := strings.Cut(scanner.Text(), ">>")
+			handle(event, data)
+		}
+	}()
+	return func() { conn.Close() }, nil
+}
+
+func (h HyprlandIPC) MoveToOutput(output string) error {
+	return h.Command("dispatch movewindow mon:" + output)
+}
@@ -0,0 +1,144 @@
+package compositor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// Message types of the sway IPC protocol.
+const (
+	SwayRunCommand = 0
+	SwaySubscribe  = 2
+	SwayGetInputs  = 100
+)
+
+var swayMagic = []byte("i3-ipc")
+
+// SwayIPC is the IPC socket of sway, or of another compositor speaking the
+// same protocol. Every call opens a connection of its own.
+type SwayIPC struct {
+	socket string
+}
+
+// Sway returns the IPC at the given socket path.
+func Sway(socket string) SwayIPC {
+	return SwayIPC{socket: socket}
+}
+
+// Call sends a message of the given type and returns the payload of the
+// reply.
+func (s SwayIPC) Call(typ uint32, payload []byte) ([]byte, error) {
+	conn, err := net.Dial("unix", s.socket)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := swayWrite(conn, typ, payload); err != nil {
+		return nil, err
+	}
+	_, data, err := swayRead(conn)
+	return data, err
+}
+
+// Command runs a sway command and returns the error of the first part of it
+// that failed.
+func (s SwayIPC) Command(command string) error {
+	data, err := s.Call(SwayRunCommand, []byte(command))
+	if err != nil {
+		return err
+	}
+
+	var results []struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if !result.Success {
+			return fmt.Errorf("compositor: sway: %s", result.Error)
+		}
+	}
+	return nil
+}
+
+// Subscribe calls changed from a background goroutine for every event of
+// the given types, such as "input". The returned function unsubscribes.
+func (s SwayIPC) Subscribe(events []string, changed func()) (func(), error) {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial("unix", s.socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := swayWrite(conn, SwaySubscribe, payload); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_, data, err := swayRead(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var reply struct {
+		Success bool `json:"success"`
+	}
+	if json.Unmarshal(data, &reply) != nil || !reply.Success {
+		conn.Close()
+		return nil, errors.New("compositor: sway refused the subscription")
+	}
+
+	go func() {
+		// Reading fails once the connection is closed.
+		for {
+			if _, _, err := swayRead(conn); err != nil {
+				return
+			}
+			changed()
+		}
+	}()
+	return func() { conn.Close() }, nil
+}
+
+func (s SwayIPC) MoveToOutput(output string) error {
+	return s.Command("move container to output " + strconv.Quote(output))
+}
+
+func swayWrite(w io.Writer, typ uint32, payload []byte) error {
+	msg := make([]byte, 0, len(swayMagic)+8+len(payload))
+	msg = append(msg, swayMagic...)
+	msg = binary.NativeEndian.AppendUint32(msg, uint32(len(payload)))
+	msg = binary.NativeEndian.AppendUint32(msg, typ)
+	msg = append(msg, payload...)
+	_, err := w.Write(msg)
+	return err
+}
+
+func swayRead(r io.Reader) (uint32, []byte, error) {
+	header := make([]byte, len(swayMagic)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	if !bytes.Equal(header[:len(swayMagic)], swayMagic) {
+		return 0, nil, errors.New("compositor: invalid sway IPC message")
+	}
+	size := binary.NativeEndian.Uint32(header[len(swayMagic):])
+	typ := binary.NativeEndian.Uint32(header[len(swayMagic)+4:])
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return typ, payload, nil
+}
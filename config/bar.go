@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const barFile = "bar.json"
+
+// Output selection modes of the bar.
+const (
+	OutputsPrimary = "primary"
+	OutputsAll     = "all"
+)
+
+// Outputs selects the monitors that get a bar. In JSON it is either
+// "primary", "all" or a list of output names such as ["eDP-1", "DP-2"].
+type Outputs struct {
+	// Mode is OutputsPrimary, OutputsAll or empty when Names is used.
+	Mode  string
+	Names []string
+}
+
+func (o Outputs) MarshalJSON() ([]byte, error) {
+	if o.Mode != "" {
+		return json.Marshal(o.Mode)
+	}
+	return json.Marshal(o.Names)
+}
+
+func (o *Outputs) UnmarshalJSON(data []byte) error {
+	var mode string
+	if err := json.Unmarshal(data, &mode); err == nil {
+		if mode != OutputsPrimary && mode != OutputsAll {
+			return fmt.Errorf("config: unknown outputs mode %q", mode)
+		}
+		*o = Outputs{Mode: mode}
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("config: outputs must be %q, %q or a list of output names", OutputsPrimary, OutputsAll)
+	}
+	*o = Outputs{Names: names}
+	return nil
+}
+
+// Screen edges the bar can be attached to.
+const (
+	PositionTop    = "top"
+	PositionBottom = "bottom"
+	PositionLeft   = "left"
+	PositionRight  = "right"
+)
+
+// Auto-hide modes of the bar.
+const (
+	AutoHideNever   = "never"
+	AutoHideAlways  = "always"
+	AutoHideOverlap = "overlap"
+)
+
+// Margins are the gaps between the bar and the screen edges, in pixels.
+type Margins struct {
+	Top    int `json:"top"`
+	Bottom int `json:"bottom"`
+	Left   int `json:"left"`
+	Right  int `json:"right"`
+}
+
+// Bar is the configuration of the main bar.
+type Bar struct {
+	Outputs Outputs `json:"outputs"`
+	// TaskbarPerOutput limits the taskbar of each bar to the windows on
+	// its own output.
+	TaskbarPerOutput bool `json:"taskbarPerOutput"`
+
+	// Position is the screen edge the bar is attached to. Bars on the
+	// left and right edges are laid out vertically.
+	Position string `json:"position"`
+	// Size is the height of a horizontal bar or the width of a vertical
+	// one, in pixels.
+	Size    int     `json:"size"`
+	Margins Margins `json:"margins"`
+	// Floating bars only take the space of their content instead of
+	// spanning the whole edge.
+	Floating bool `json:"floating"`
+
+	// AutoHide slides the bar out of the screen until the pointer touches
+	// its edge: with AutoHideAlways whenever the pointer is not over it,
+	// with AutoHideOverlap only while a window covers it.
+	AutoHide string `json:"autoHide"`
+	// HideDelay is how long the bar stays after the pointer left it, in
+	// milliseconds.
+	HideDelay int `json:"hideDelay"`
+
+	Layout Layout `json:"layout"`
+}
+
+// DefaultBar returns the configuration used when there is no bar.json.
+func DefaultBar() Bar {
+	return Bar{
+		Outputs:   Outputs{Mode: OutputsAll},
+		Position:  PositionBottom,
+		Size:      75,
+		AutoHide:  AutoHideNever,
+		HideDelay: 1000,
+		Layout:    DefaultLayout(),
+	}
+}
+
+// Vertical reports whether the bar is attached to a side edge.
+func (b Bar) Vertical() bool {
+	return b.Position == PositionLeft || b.Position == PositionRight
+}
+
+// LoadBar reads the bar configuration. Invalid values are reported as an
+// error and replaced by their defaults.
+func LoadBar() (Bar, error) {
+	b := DefaultBar()
+	if err := load(barFile, &b); err != nil {
+		return b, err
+	}
+
+	var err error
+	switch b.Position {
+	case PositionTop, PositionBottom, PositionLeft, PositionRight:
+	default:
+		err = fmt.Errorf("config: unknown bar position %q", b.Position)
+		b.Position = DefaultBar().Position
+	}
+	switch b.AutoHide {
+	case AutoHideNever, AutoHideAlways, AutoHideOverlap:
+	default:
+		err = fmt.Errorf("config: unknown auto-hide mode %q", b.AutoHide)
+		b.AutoHide = DefaultBar().AutoHide
+	}
+	b.Size = max(b.Size, 0)
+	b.HideDelay = max(b.HideDelay, 0)
+	return b, err
+}
+
+// WatchBar calls changed from a background goroutine whenever the bar
+// configuration file changes. The returned function stops watching.
+func WatchBar(changed func()) (func(), error) {
+	return watch(barFile, changed)
+}
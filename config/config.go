@@ -0,0 +1,69 @@
+// Package config reads and writes the settings of the shell. Every part of
+// the configuration is a JSON file in $XDG_CONFIG_HOME/auru.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Dir returns the directory the configuration files are stored in.
+func Dir() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "/"
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "auru")
+}
+
+// Path returns the path of the configuration file with the given name.
+func Path(name string) string {
+	return filepath.Join(Dir(), name)
+}
+
+// load decodes the configuration file name into v. A missing file is not an
+// error and leaves v untouched, so callers fill v with defaults first.
+func load(name string, v any) error {
+	data, err := os.ReadFile(Path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// save writes v to the configuration file name, replacing it atomically.
+func save(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(Dir(), name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), Path(name))
+}
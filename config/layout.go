@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Layout lists the modules in the three sections of the bar. On a vertical
+// bar, Left is the top section and Right the bottom one.
+type Layout struct {
+	Left   []Module `json:"left"`
+	Center []Module `json:"center"`
+	Right  []Module `json:"right"`
+}
+
+// DefaultLayout returns the layout used when bar.json has none.
+func DefaultLayout() Layout {
+	return Layout{
+		Left:   []Module{{Type: "taskbar"}},
+		Center: []Module{{Type: "launcher"}},
+		Right: []Module{
+			{Type: "tray"},
+			{Type: "keyboard"},
+			{Type: "status"},
+			{Type: "clock"},
+			{Type: "notifications"},
+		},
+	}
+}
+
+// Module is an entry of the bar layout. In JSON it is either the type of
+// the module, such as "clock", or an object with the type in "type" and the
+// options of the module in the other fields.
+type Module struct {
+	Type string
+	// Options is the JSON object of the entry, or nil for a plain type.
+	Options json.RawMessage
+}
+
+func (m Module) MarshalJSON() ([]byte, error) {
+	if m.Options != nil {
+		return m.Options, nil
+	}
+	return json.Marshal(m.Type)
+}
+
+func (m *Module) UnmarshalJSON(data []byte) error {
+	var typ string
+	if err := json.Unmarshal(data, &typ); err == nil {
+		*m = Module{Type: typ}
+		return nil
+	}
+
+	var obj struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return errors.New("config: a layout module must be a module type or an object")
+	}
+	if obj.Type == "" {
+		return errors.New("config: layout module without a type")
+	}
+	*m = Module{Type: obj.Type, Options: append(json.RawMessage(nil), data...)}
+	return nil
+}
+
+// Decode reads the options of the module into v. Modules without options
+// leave v untouched.
+func (m Module) Decode(v any) error {
+	if m.Options == nil {
+		return nil
+	}
+	return json.Unmarshal(m.Options, v)
+}
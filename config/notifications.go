@@ -0,0 +1,74 @@
+package config
+
+import "fmt"
+
+const notificationsFile = "notifications.json"
+
+// Screen corners notification popups can appear in.
+const (
+	CornerTopLeft     = "top-left"
+	CornerTopRight    = "top-right"
+	CornerBottomLeft  = "bottom-left"
+	CornerBottomRight = "bottom-right"
+)
+
+// Notifications is the configuration of the notification popups.
+type Notifications struct {
+	Corner string `json:"corner"`
+	// Timeout and LowTimeout are how long a popup of normal and low
+	// urgency is shown, in seconds, when the application leaves it to the
+	// server.
+	Timeout    int `json:"timeout"`
+	LowTimeout int `json:"lowTimeout"`
+	// CriticalTimeout is how long a popup of critical urgency is shown,
+	// whatever the application asks for. With 0 it stays until dismissed.
+	CriticalTimeout int `json:"criticalTimeout"`
+	// MaxVisible is how many popups are shown at once. Further ones wait
+	// until there is room.
+	MaxVisible int `json:"maxVisible"`
+	// Sounds plays the sounds notifications ask for.
+	Sounds bool `json:"sounds"`
+	// SoundPlayer is the program, and its arguments, that plays sound
+	// files; the path of the file is appended. Empty for the first of
+	// pw-play, paplay and aplay that is installed.
+	SoundPlayer []string `json:"soundPlayer"`
+}
+
+// DefaultNotifications returns the configuration used when there is no
+// notifications.json.
+func DefaultNotifications() Notifications {
+	return Notifications{
+		Corner:          CornerTopRight,
+		Timeout:         5,
+		LowTimeout:      3,
+		CriticalTimeout: 0,
+		MaxVisible:      3,
+		Sounds:          true,
+	}
+}
+
+// LoadNotifications reads the notification popup configuration. Invalid
+// values are reported as an error and replaced by their defaults.
+func LoadNotifications() (Notifications, error) {
+	n := DefaultNotifications()
+	if err := load(notificationsFile, &n); err != nil {
+		return n, err
+	}
+
+	var err error
+	switch n.Corner {
+	case CornerTopLeft, CornerTopRight, CornerBottomLeft, CornerBottomRight:
+	default:
+		err = fmt.Errorf("config: unknown notification corner %q", n.Corner)
+		n.Corner = DefaultNotifications().Corner
+	}
+	if n.Timeout <= 0 {
+		n.Timeout = DefaultNotifications().Timeout
+	}
+	if n.LowTimeout <= 0 {
+		n.LowTimeout = DefaultNotifications().LowTimeout
+	}
+	n.CriticalTimeout = max(n.CriticalTimeout, 0)
+	n.MaxVisible = max(n.MaxVisible, 1)
+	return n, err
+}
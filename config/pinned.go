@@ -0,0 +1,42 @@
+package config
+
+import (
+	"slices"
+)
+
+const pinnedFile = "pinned.json"
+
+// Pinned lists the applications pinned to the bar by their desktop file ID,
+// for example "org.gnome.Nautilus", in the order they are shown.
+type Pinned struct {
+	Apps []string `json:"apps"`
+}
+
+// LoadPinned reads the pinned applications.
+func LoadPinned() (Pinned, error) {
+	var p Pinned
+	err := load(pinnedFile, &p)
+	return p, err
+}
+
+// Save writes the pinned applications.
+func (p Pinned) Save() error {
+	return save(pinnedFile, p)
+}
+
+// Contains reports whether the application with the given ID is pinned.
+func (p Pinned) Contains(id string) bool {
+	return slices.Contains(p.Apps, id)
+}
+
+// Pin appends the application with the given ID unless it is pinned already.
+func (p *Pinned) Pin(id string) {
+	if !p.Contains(id) {
+		p.Apps = append(p.Apps, id)
+	}
+}
+
+// Unpin removes the application with the given ID.
+func (p *Pinned) Unpin(id string) {
+	p.Apps = slices.DeleteFunc(p.Apps, func(a string) bool { return a == id })
+}
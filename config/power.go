@@ -0,0 +1,58 @@
+package config
+
+import "fmt"
+
+const powerFile = "power.json"
+
+// Actions taken when the battery is critically low.
+const (
+	CriticalActionNone      = "none"
+	CriticalActionSuspend   = "suspend"
+	CriticalActionHibernate = "hibernate"
+	CriticalActionPowerOff  = "poweroff"
+)
+
+// Power is the configuration of the battery warnings.
+type Power struct {
+	// LowLevel is the charge in percent below which a warning is shown
+	// while discharging.
+	LowLevel int `json:"lowLevel"`
+	// CriticalLevel is the charge in percent below which CriticalAction
+	// is taken.
+	CriticalLevel  int    `json:"criticalLevel"`
+	CriticalAction string `json:"criticalAction"`
+	// ActionDelay is how long the user has to cancel the critical action,
+	// in seconds.
+	ActionDelay int `json:"actionDelay"`
+}
+
+// DefaultPower returns the configuration used when there is no power.json.
+func DefaultPower() Power {
+	return Power{
+		LowLevel:       15,
+		CriticalLevel:  5,
+		CriticalAction: CriticalActionSuspend,
+		ActionDelay:    60,
+	}
+}
+
+// LoadPower reads the battery warning configuration. Invalid values are
+// reported as an error and replaced by their defaults.
+func LoadPower() (Power, error) {
+	p := DefaultPower()
+	if err := load(powerFile, &p); err != nil {
+		return p, err
+	}
+
+	var err error
+	switch p.CriticalAction {
+	case CriticalActionNone, CriticalActionSuspend, CriticalActionHibernate, CriticalActionPowerOff:
+	default:
+		err = fmt.Errorf("config: unknown critical battery action %q", p.CriticalAction)
+		p.CriticalAction = DefaultPower().CriticalAction
+	}
+	p.LowLevel = min(max(p.LowLevel, 0), 100)
+	p.CriticalLevel = min(max(p.CriticalLevel, 0), p.LowLevel)
+	p.ActionDelay = max(p.ActionDelay, 0)
+	return p, err
+}
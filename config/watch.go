@@ -0,0 +1,57 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// watch calls changed whenever the configuration file name is written,
+// replaced or removed. The configuration directory is watched rather than
+// the file itself, so that files that do not exist yet and files replaced
+// by renaming are noticed too.
+func watch(name string, changed func()) (func(), error) {
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return nil, err
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	mask := uint32(syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE)
+	if _, err := syscall.InotifyAddWatch(fd, Dir(), mask); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	// A non-blocking descriptor wrapped in an os.File uses the runtime
+	// poller, so closing the file ends the pending read below.
+	f := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+				start := off + syscall.SizeofInotifyEvent
+				off = start + int(ev.Len)
+				if off > n {
+					break
+				}
+				evName := string(bytes.TrimRight(buf[start:off], "\x00"))
+				if evName == name {
+					changed()
+				}
+			}
+		}
+	}()
+
+	return func() { f.Close() }, nil
+}
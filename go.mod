@@ -6,7 +6,9 @@ require (
 	github.com/AuruTeam/desktoplib v0.0.0-20250223152628-950d1d75967b
 	github.com/AuruTeam/libxdg-go v0.0.0-20250301094649-51ce3be1ff64
 	github.com/dlasky/gotk3-layershell v0.0.0-20240515133811-5c5115f0d774
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/gotk3/gotk3 v0.6.5-0.20240618185848-ff349ae13f56
+	github.com/jfreymuth/pulse v0.1.1
 )
 
 require (
@@ -16,9 +18,7 @@ require (
 	github.com/gdamore/tcell v1.4.0 // indirect
 	github.com/gek64/displayController v1.0.2 // indirect
 	github.com/go-ole/go-ole v1.3.0 // indirect
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/itchyny/volume-go v0.2.2 // indirect
-	github.com/jfreymuth/pulse v0.1.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.0.3 // indirect
 	github.com/mattn/go-runewidth v0.0.7 // indirect
 	github.com/moutend/go-wca v0.3.0 // indirect
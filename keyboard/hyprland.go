@@ -0,0 +1,98 @@
+package keyboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AuruTeam/desktop/compositor"
+)
+
+type hyprland struct {
+	ipc compositor.HyprlandIPC
+}
+
+// Hyprland returns a backend that uses the IPC sockets of the Hyprland
+// instance with the given signature.
+func Hyprland(signature string) Backend {
+	return hyprland{ipc: compositor.Hyprland(signature)}
+}
+
+type hyprlandKeyboard struct {
+	Name         string `json:"name"`
+	Layout       string `json:"layout"`
+	Variant      string `json:"variant"`
+	ActiveKeymap string `json:"active_keymap"`
+	// ActiveIndex is only reported by newer versions.
+	ActiveIndex *int `json:"active_layout_index"`
+	Main        bool `json:"main"`
+}
+
+func (h hyprland) keyboard() (hyprlandKeyboard, error) {
+	data, err := h.ipc.Call("j/devices")
+	if err != nil {
+		return hyprlandKeyboard{}, err
+	}
+
+	var devices struct {
+		Keyboards []hyprlandKeyboard `json:"keyboards"`
+	}
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return hyprlandKeyboard{}, err
+	}
+	if len(devices.Keyboards) == 0 {
+		return hyprlandKeyboard{}, errors.New("keyboard: Hyprland reports no keyboard")
+	}
+	for _, kb := range devices.Keyboards {
+		if kb.Main {
+			return kb, nil
+		}
+	}
+	return devices.Keyboards[0], nil
+}
+
+func (h hyprland) State() (State, error) {
+	kb, err := h.keyboard()
+	if err != nil {
+		return State{}, err
+	}
+
+	// Hyprland only reports the codes of the layouts and the name of the
+	// active one.
+	state := State{Active: -1, ActiveName: kb.ActiveKeymap}
+	variants := strings.Split(kb.Variant, ",")
+	for i, code := range strings.Split(kb.Layout, ",") {
+		code = strings.TrimSpace(code)
+		variant := ""
+		if i < len(variants) {
+			variant = strings.TrimSpace(variants[i])
+		}
+		state.Layouts = append(state.Layouts, Layout{Name: layoutName(code, variant), Code: code})
+	}
+
+	if kb.ActiveIndex != nil && *kb.ActiveIndex < len(state.Layouts) {
+		state.Active = *kb.ActiveIndex
+		state.Layouts[state.Active].Name = kb.ActiveKeymap
+	} else {
+		state.Active = slices.IndexFunc(state.Layouts, func(l Layout) bool { return l.Name != "" && l.Name == kb.ActiveKeymap })
+	}
+	return state, nil
+}
+
+func (h hyprland) SetLayout(index int) error {
+	kb, err := h.keyboard()
+	if err != nil {
+		return err
+	}
+	return h.ipc.Command(fmt.Sprintf("switchxkblayout %s %d", kb.Name, index))
+}
+
+func (h hyprland) Watch(changed func()) (func(), error) {
+	return h.ipc.Listen(func(event, _ string) {
+		if event == "activelayout" {
+			changed()
+		}
+	})
+}
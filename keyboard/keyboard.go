@@ -0,0 +1,74 @@
+// Package keyboard reports and switches the active keyboard layout. The
+// layouts are managed by a Backend, which talks to the compositor or to a
+// layout daemon.
+package keyboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AuruTeam/desktop/status"
+)
+
+// ErrUnsupported is returned by Detect when no known backend is running.
+var ErrUnsupported = errors.New("keyboard: no supported layout backend found")
+
+// Layout is a keyboard layout known to a backend.
+type Layout struct {
+	// Name is the description of the layout, such as "English (US)".
+	Name string
+	// Code is the XKB layout code, such as "us". Backends that only know
+	// one of Name and Code look the other one up in the XKB rules, so
+	// either may be empty for layouts the rules do not list.
+	Code string
+}
+
+// State is the list of layouts of a backend and the active one.
+type State struct {
+	Layouts []Layout
+	// Active is the index of the active layout in Layouts, or -1 if only
+	// its name is known.
+	Active     int
+	ActiveName string
+}
+
+// Backend reports and switches the layout of the keyboards of the session.
+// Its Watch method reports layout changes.
+type Backend interface {
+	status.Source
+	State() (State, error)
+	// SetLayout activates the layout at index in the Layouts of State.
+	SetLayout(index int) error
+}
+
+// Open returns the backend with the given name, "sway" or "hyprland", for
+// the running session. The name "auto" picks the backend of the running
+// compositor.
+func Open(name string) (Backend, error) {
+	switch name {
+	case "auto":
+		return Detect()
+	case "sway":
+		if socket := os.Getenv("SWAYSOCK"); socket != "" {
+			return Sway(socket), nil
+		}
+		return nil, errors.New("keyboard: SWAYSOCK is not set")
+	case "hyprland":
+		if signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE"); signature != "" {
+			return Hyprland(signature), nil
+		}
+		return nil, errors.New("keyboard: HYPRLAND_INSTANCE_SIGNATURE is not set")
+	}
+	return nil, fmt.Errorf("keyboard: unknown backend %q", name)
+}
+
+// Detect returns the backend of the running compositor.
+func Detect() (Backend, error) {
+	for _, name := range []string{"sway", "hyprland"} {
+		if backend, err := Open(name); err == nil {
+			return backend, nil
+		}
+	}
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,114 @@
+package keyboard
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRules = `<?xml version="1.0" encoding="UTF-8"?>
+<xkbConfigRegistry version="1.1">
+  <layoutList>
+    <layout>
+      <configItem>
+        <name>us</name>
+        <shortDescription>en</shortDescription>
+        <description>English (US)</description>
+      </configItem>
+      <variantList>
+        <variant>
+          <configItem>
+            <name>intl</name>
+            <description>English (US, intl., with dead keys)</description>
+          </configItem>
+        </variant>
+      </variantList>
+    </layout>
+    <layout>
+      <configItem>
+        <name>ru</name>
+        <description>Russian</description>
+      </configItem>
+    </layout>
+  </layoutList>
+</xkbConfigRegistry>`
+
+func TestParseXKBRules(t *testing.T) {
+	got, err := parseXKBRules(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []xkbLayout{
+		{code: "us", name: "English (US)"},
+		{code: "us", variant: "intl", name: "English (US, intl., with dead keys)"},
+		{code: "ru", name: "Russian"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseXKBRules = %+v, want %+v", got, want)
+	}
+}
+
+func TestHyprlandState(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "rules"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "rules", "evdev.xml"), []byte(testRules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XKB_CONFIG_ROOT", root)
+
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	dir := filepath.Join(runtime, "hypr", "test")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("unix", filepath.Join(dir, ".socket.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Older versions of Hyprland do not report the index of the active
+	// layout.
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			request := make([]byte, 64)
+			conn.Read(request)
+			io.WriteString(conn, `{"keyboards": [{
+				"name": "at-translated-set-2-keyboard",
+				"layout": "us,ru,us",
+				"variant": ",,intl",
+				"active_keymap": "Russian",
+				"main": true
+			}]}`)
+			conn.Close()
+		}
+	}()
+
+	state, err := Hyprland("test").State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := State{
+		Layouts: []Layout{
+			{Name: "English (US)", Code: "us"},
+			{Name: "Russian", Code: "ru"},
+			{Name: "English (US, intl., with dead keys)", Code: "us"},
+		},
+		Active:     1,
+		ActiveName: "Russian",
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("State = %+v, want %+v", state, want)
+	}
+}
@@ -0,0 +1,57 @@
+package keyboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/AuruTeam/desktop/compositor"
+)
+
+type sway struct {
+	ipc compositor.SwayIPC
+}
+
+// Sway returns a backend that uses the IPC socket of sway, or of another
+// compositor speaking the same protocol.
+func Sway(socket string) Backend {
+	return sway{ipc: compositor.Sway(socket)}
+}
+
+func (s sway) State() (State, error) {
+	data, err := s.ipc.Call(compositor.SwayGetInputs, nil)
+	if err != nil {
+		return State{}, err
+	}
+
+	var inputs []struct {
+		Type        string   `json:"type"`
+		LayoutNames []string `json:"xkb_layout_names"`
+		Active      int      `json:"xkb_active_layout_index"`
+		ActiveName  string   `json:"xkb_active_layout_name"`
+	}
+	if err := json.Unmarshal(data, &inputs); err != nil {
+		return State{}, err
+	}
+
+	for _, input := range inputs {
+		if input.Type != "keyboard" || len(input.LayoutNames) == 0 {
+			continue
+		}
+		state := State{Active: input.Active, ActiveName: input.ActiveName}
+		// sway only reports the names of the layouts.
+		for _, name := range input.LayoutNames {
+			state.Layouts = append(state.Layouts, Layout{Name: name, Code: layoutCode(name)})
+		}
+		return state, nil
+	}
+	return State{}, errors.New("keyboard: sway reports no keyboard")
+}
+
+func (s sway) SetLayout(index int) error {
+	return s.ipc.Command(fmt.Sprintf("input type:keyboard xkb_switch_layout %d", index))
+}
+
+func (s sway) Watch(changed func()) (func(), error) {
+	return s.ipc.Subscribe([]string{"input"}, changed)
+}
@@ -0,0 +1,91 @@
+package keyboard
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// xkbLayout is a layout or a variant of one listed by the XKB rules.
+type xkbLayout struct {
+	code    string
+	variant string
+	// name is the description XKB gives the layout in keymaps, such as
+	// "English (US)", which is what compositors report as its name.
+	name string
+}
+
+var xkbRegistry struct {
+	once    sync.Once
+	layouts []xkbLayout
+}
+
+// xkbLayouts returns the layouts of the XKB rules of the system. Compositors
+// only report either the codes or the names of the configured layouts, and
+// the rules map between both.
+func xkbLayouts() []xkbLayout {
+	xkbRegistry.once.Do(func() {
+		root := os.Getenv("XKB_CONFIG_ROOT")
+		if root == "" {
+			root = "/usr/share/X11/xkb"
+		}
+		f, err := os.Open(filepath.Join(root, "rules", "evdev.xml"))
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		xkbRegistry.layouts, _ = parseXKBRules(f)
+	})
+	return xkbRegistry.layouts
+}
+
+func parseXKBRules(r io.Reader) ([]xkbLayout, error) {
+	type configItem struct {
+		Name        string `xml:"name"`
+		Description string `xml:"description"`
+	}
+	var registry struct {
+		Layouts []struct {
+			ConfigItem configItem `xml:"configItem"`
+			Variants   []struct {
+				ConfigItem configItem `xml:"configItem"`
+			} `xml:"variantList>variant"`
+		} `xml:"layoutList>layout"`
+	}
+	if err := xml.NewDecoder(r).Decode(&registry); err != nil {
+		return nil, err
+	}
+
+	var layouts []xkbLayout
+	for _, l := range registry.Layouts {
+		layouts = append(layouts, xkbLayout{code: l.ConfigItem.Name, name: l.ConfigItem.Description})
+		for _, v := range l.Variants {
+			layouts = append(layouts, xkbLayout{code: l.ConfigItem.Name, variant: v.ConfigItem.Name, name: v.ConfigItem.Description})
+		}
+	}
+	return layouts, nil
+}
+
+// layoutName returns the name of the layout with the given code and
+// variant, or "" if the XKB rules do not list it.
+func layoutName(code, variant string) string {
+	for _, l := range xkbLayouts() {
+		if l.code == code && l.variant == variant {
+			return l.name
+		}
+	}
+	return ""
+}
+
+// layoutCode returns the code of the layout with the given name, or "" if
+// the XKB rules do not list it.
+func layoutCode(name string) string {
+	for _, l := range xkbLayouts() {
+		if l.name == name {
+			return l.code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package network
+
+import (
+	"context"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	agentManagerPath  = "/org/freedesktop/NetworkManager/AgentManager"
+	agentManagerIface = "org.freedesktop.NetworkManager.AgentManager"
+	agentPath         = "/org/freedesktop/NetworkManager/SecretAgent"
+	agentIface        = "org.freedesktop.NetworkManager.SecretAgent"
+	agentIdentifier   = "auru-shell"
+
+	errUserCanceled = agentIface + ".UserCanceled"
+	errNoSecrets    = agentIface + ".NoSecrets"
+)
+
+// Secret request flags, see NMSecretAgentGetSecretsFlags.
+const (
+	flagAllowInteraction = 0x1
+	flagRequestNew       = 0x2
+)
+
+// SecretRequest is a request of NetworkManager for the secret of a
+// connection it is activating.
+type SecretRequest struct {
+	// Connection is the name of the connection.
+	Connection string
+	// SSID is the network of Wi-Fi connections.
+	SSID string
+	// Retry is set when the secret NetworkManager had did not work.
+	Retry bool
+}
+
+// PromptFunc asks the user for a secret. It returns false if the user did
+// not give one. ctx is cancelled when NetworkManager no longer needs the
+// secret.
+type PromptFunc func(ctx context.Context, req SecretRequest) (secret string, ok bool)
+
+// Agent provides secrets to NetworkManager by asking the user.
+type Agent struct {
+	conn   *dbus.Conn
+	prompt PromptFunc
+
+	mu      sync.Mutex
+	pending map[string]*secretsRequest
+}
+
+// secretsRequest is a GetSecrets call waiting for the user.
+type secretsRequest struct {
+	cancel context.CancelFunc
+}
+
+// RegisterAgent registers a secret agent that calls prompt for Wi-Fi
+// passphrases and 802.1X passwords. The agent is registered again when
+// NetworkManager restarts.
+func RegisterAgent(prompt PromptFunc) (*Agent, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Agent{conn: conn, prompt: prompt, pending: make(map[string]*secretsRequest)}
+	if err := conn.Export(a, agentPath, agentIface); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	err = conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, nmName),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	signals := make(chan *dbus.Signal, 4)
+	conn.Signal(signals)
+
+	if err := a.register(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go func() {
+		// The channel is closed together with the connection.
+		for s := range signals {
+			var name, oldOwner, newOwner string
+			if dbus.Store(s.Body, &name, &oldOwner, &newOwner) == nil && newOwner != "" {
+				a.register()
+			}
+		}
+	}()
+	return a, nil
+}
+
+func (a *Agent) register() error {
+	return a.conn.Object(nmName, agentManagerPath).Call(agentManagerIface+".Register", 0, agentIdentifier).Err
+}
+
+// Close unregisters the agent.
+func (a *Agent) Close() error {
+	a.conn.Object(nmName, agentManagerPath).Call(agentManagerIface+".Unregister", 0)
+	return a.conn.Close()
+}
+
+// GetSecrets is called by NetworkManager when a connection needs secrets.
+func (a *Agent) GetSecrets(settings map[string]map[string]dbus.Variant, path dbus.ObjectPath, setting string, hints []string, flags uint32) (map[string]map[string]dbus.Variant, *dbus.Error) {
+	var key string
+	switch setting {
+	case wirelessSecSetting:
+		key = "psk"
+		if mgmt, _ := settings[wirelessSecSetting]["key-mgmt"].Value().(string); mgmt == "none" {
+			key = "wep-key0"
+		}
+	case "802-1x":
+		key = "password"
+	default:
+		// VPN plugins ask for their secrets themselves.
+		return nil, dbus.NewError(errNoSecrets, nil)
+	}
+	if flags&flagAllowInteraction == 0 {
+		return nil, dbus.NewError(errNoSecrets, nil)
+	}
+
+	req := SecretRequest{Retry: flags&flagRequestNew != 0}
+	req.Connection, _ = settings["connection"]["id"].Value().(string)
+	ssid, _ := settings[wirelessSetting]["ssid"].Value().([]byte)
+	req.SSID = string(ssid)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request := &secretsRequest{cancel: cancel}
+	id := string(path) + "/" + setting
+	a.mu.Lock()
+	if previous, ok := a.pending[id]; ok {
+		previous.cancel()
+	}
+	a.pending[id] = request
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		cancel()
+		// A newer request for the same secrets may have replaced this
+		// one.
+		if a.pending[id] == request {
+			delete(a.pending, id)
+		}
+		a.mu.Unlock()
+	}()
+
+	secret, ok := a.prompt(ctx, req)
+	if !ok || ctx.Err() != nil {
+		return nil, dbus.NewError(errUserCanceled, nil)
+	}
+	return map[string]map[string]dbus.Variant{
+		setting: {key: dbus.MakeVariant(secret)},
+	}, nil
+}
+
+// CancelGetSecrets is called by NetworkManager when it no longer needs the
+// secrets it asked for.
+func (a *Agent) CancelGetSecrets(path dbus.ObjectPath, setting string) *dbus.Error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if request, ok := a.pending[string(path)+"/"+setting]; ok {
+		request.cancel()
+	}
+	return nil
+}
+
+// SaveSecrets is called when secrets should be stored by the agent. The
+// agent stores nothing: NetworkManager keeps the secrets itself.
+func (a *Agent) SaveSecrets(settings map[string]map[string]dbus.Variant, path dbus.ObjectPath) *dbus.Error {
+	return nil
+}
+
+// DeleteSecrets is called when a connection is deleted.
+func (a *Agent) DeleteSecrets(settings map[string]map[string]dbus.Variant, path dbus.ObjectPath) *dbus.Error {
+	return nil
+}
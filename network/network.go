@@ -0,0 +1,502 @@
+// Package network manages network connections through the D-Bus API of
+// NetworkManager: it lists devices, Wi-Fi access points and saved
+// connections, activates and deactivates connections, and answers the
+// secret requests of NetworkManager as a secret agent.
+package network
+
+import (
+	"cmp"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/AuruTeam/desktop/watch"
+)
+
+// Bus name, object paths and interfaces of NetworkManager.
+const (
+	nmName             = "org.freedesktop.NetworkManager"
+	nmPath             = "/org/freedesktop/NetworkManager"
+	nmIface            = "org.freedesktop.NetworkManager"
+	settingsPath       = "/org/freedesktop/NetworkManager/Settings"
+	settingsIface      = "org.freedesktop.NetworkManager.Settings"
+	connectionIface    = "org.freedesktop.NetworkManager.Settings.Connection"
+	activeIface        = "org.freedesktop.NetworkManager.Connection.Active"
+	deviceIface        = "org.freedesktop.NetworkManager.Device"
+	wirelessIface      = "org.freedesktop.NetworkManager.Device.Wireless"
+	accessPointIface   = "org.freedesktop.NetworkManager.AccessPoint"
+	propertiesIface    = "org.freedesktop.DBus.Properties"
+	noObject           = dbus.ObjectPath("/")
+	wirelessSetting    = "802-11-wireless"
+	wirelessSecSetting = "802-11-wireless-security"
+)
+
+// Connection types, as in the "connection.type" setting.
+const (
+	TypeEthernet  = "802-3-ethernet"
+	TypeWireless  = "802-11-wireless"
+	TypeVPN       = "vpn"
+	TypeWireGuard = "wireguard"
+
+	// Newer versions of NetworkManager manage the loopback interface too,
+	// which is of no interest to users.
+	typeLoopback = "loopback"
+)
+
+// DeviceType is the kind of a network device.
+type DeviceType uint32
+
+// Device types shown by the shell.
+const (
+	DeviceEthernet DeviceType = 1
+	DeviceWifi     DeviceType = 2
+)
+
+// ActiveState is the state of an active connection.
+type ActiveState uint32
+
+const (
+	ActiveUnknown ActiveState = iota
+	Activating
+	Activated
+	Deactivating
+	Deactivated
+)
+
+// Device is a network interface.
+type Device struct {
+	Path      dbus.ObjectPath
+	Interface string
+	Type      DeviceType
+	// Managed devices can be used by NetworkManager.
+	Managed bool
+}
+
+// AccessPoint is a Wi-Fi network in range. Access points of the same network
+// are reported once, by the strongest.
+type AccessPoint struct {
+	Path   dbus.ObjectPath
+	Device dbus.ObjectPath
+	SSID   string
+	// Strength is the signal quality in percent.
+	Strength uint8
+	// Security is "WPA3", "WPA2", "WPA", "WEP", "802.1X" or empty for open
+	// networks.
+	Security string
+	// Active is set for the network the device is connected to.
+	Active bool
+	// Connection is the saved connection for the network, if any.
+	Connection dbus.ObjectPath
+}
+
+// Secured reports whether the network needs a secret.
+func (ap AccessPoint) Secured() bool {
+	return ap.Security != ""
+}
+
+// Enterprise reports whether the network needs more than a passphrase.
+func (ap AccessPoint) Enterprise() bool {
+	return ap.Security == "802.1X"
+}
+
+// ValidPassphrase reports whether NetworkManager accepts passphrase for the
+// network. WPA and WPA2 passphrases have 8 to 63 characters, or are a key
+// of 64 hex digits.
+func (ap AccessPoint) ValidPassphrase(passphrase string) bool {
+	switch ap.Security {
+	case "WPA", "WPA2":
+		if len(passphrase) == 64 {
+			_, err := hex.DecodeString(passphrase)
+			return err == nil
+		}
+		return len(passphrase) >= 8 && len(passphrase) <= 63
+	}
+	return passphrase != ""
+}
+
+// Connection is a saved connection.
+type Connection struct {
+	Path dbus.ObjectPath
+	ID   string
+	UUID string
+	// Type is one of the Type constants or another NetworkManager
+	// connection type.
+	Type string
+	// SSID is the network of Wi-Fi connections.
+	SSID string
+}
+
+// ActiveConnection is a connection that is active or being activated.
+type ActiveConnection struct {
+	Path  dbus.ObjectPath
+	ID    string
+	UUID  string
+	Type  string
+	State ActiveState
+	VPN   bool
+}
+
+// State is a snapshot of NetworkManager.
+type State struct {
+	WirelessEnabled bool
+	Devices         []Device
+	AccessPoints    []AccessPoint
+	Connections     []Connection
+	Active          []ActiveConnection
+}
+
+// Client is a connection to NetworkManager.
+type Client struct {
+	conn *dbus.Conn
+
+	listeners watch.Listeners[struct{}]
+
+	mu       sync.Mutex
+	watching bool
+}
+
+// Connect connects to NetworkManager on the system bus.
+func Connect() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Object(nmName, nmPath).Call(propertiesIface+".Get", 0, nmIface, "Version").Err; err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// watchedIfaces are the interfaces whose property changes Watch reports.
+// Access points are left out: their signal strength changes every few
+// seconds.
+var watchedIfaces = []string{nmIface, deviceIface, wirelessIface, activeIface}
+
+// watchDelay is how long Watch waits for further changes before reporting
+// them, as NetworkManager sends several signals for one change.
+const watchDelay = 200 * time.Millisecond
+
+// Watch calls changed every time the properties of NetworkManager, its
+// devices or active connections change, or a connection is added or
+// removed, until stop is called. It makes a Client a status.Source.
+func (c *Client) Watch(changed func()) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.watching {
+		for _, iface := range watchedIfaces {
+			err := c.conn.AddMatchSignal(
+				dbus.WithMatchSender(nmName),
+				dbus.WithMatchInterface(propertiesIface),
+				dbus.WithMatchMember("PropertiesChanged"),
+				dbus.WithMatchArg(0, iface),
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err := c.conn.AddMatchSignal(
+			dbus.WithMatchSender(nmName),
+			dbus.WithMatchObjectPath(settingsPath),
+			dbus.WithMatchInterface(settingsIface),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		signals := make(chan *dbus.Signal, 64)
+		c.conn.Signal(signals)
+		notify := watch.Coalesce(watchDelay, func() { c.listeners.Notify(struct{}{}) })
+		go func() {
+			// The channel is closed together with the connection.
+			for range signals {
+				notify()
+			}
+		}()
+		c.watching = true
+	}
+
+	return c.listeners.Add(func(struct{}) { changed() }), nil
+}
+
+func (c *Client) properties(path dbus.ObjectPath, iface string) (map[string]dbus.Variant, error) {
+	var props map[string]dbus.Variant
+	err := c.conn.Object(nmName, path).Call(propertiesIface+".GetAll", 0, iface).Store(&props)
+	return props, err
+}
+
+// State reads the devices, access points and connections.
+func (c *Client) State() (State, error) {
+	nm, err := c.properties(nmPath, nmIface)
+	if err != nil {
+		return State{}, err
+	}
+
+	var state State
+	state.WirelessEnabled, _ = nm["WirelessEnabled"].Value().(bool)
+
+	if state.Connections, err = c.connections(); err != nil {
+		return State{}, err
+	}
+	known := make(map[string]dbus.ObjectPath)
+	for _, conn := range state.Connections {
+		if conn.Type == TypeWireless && known[conn.SSID] == "" {
+			known[conn.SSID] = conn.Path
+		}
+	}
+
+	activePaths, _ := nm["ActiveConnections"].Value().([]dbus.ObjectPath)
+	for _, path := range activePaths {
+		props, err := c.properties(path, activeIface)
+		if err != nil {
+			// It went away in the meantime.
+			continue
+		}
+		active := ActiveConnection{Path: path}
+		active.ID, _ = props["Id"].Value().(string)
+		active.UUID, _ = props["Uuid"].Value().(string)
+		active.Type, _ = props["Type"].Value().(string)
+		activeState, _ := props["State"].Value().(uint32)
+		active.State = ActiveState(activeState)
+		active.VPN, _ = props["Vpn"].Value().(bool)
+		if active.Type == typeLoopback {
+			continue
+		}
+		state.Active = append(state.Active, active)
+	}
+
+	var devicePaths []dbus.ObjectPath
+	if err := c.conn.Object(nmName, nmPath).Call(nmIface+".GetDevices", 0).Store(&devicePaths); err != nil {
+		return State{}, err
+	}
+	for _, path := range devicePaths {
+		props, err := c.properties(path, deviceIface)
+		if err != nil {
+			continue
+		}
+		device := Device{Path: path}
+		device.Interface, _ = props["Interface"].Value().(string)
+		deviceType, _ := props["DeviceType"].Value().(uint32)
+		device.Type = DeviceType(deviceType)
+		device.Managed, _ = props["Managed"].Value().(bool)
+		state.Devices = append(state.Devices, device)
+
+		if device.Type == DeviceWifi && device.Managed {
+			state.AccessPoints = append(state.AccessPoints, c.accessPoints(path, known)...)
+		}
+	}
+
+	state.AccessPoints = dedupAccessPoints(state.AccessPoints)
+	return state, nil
+}
+
+func (c *Client) connections() ([]Connection, error) {
+	var paths []dbus.ObjectPath
+	if err := c.conn.Object(nmName, settingsPath).Call(settingsIface+".ListConnections", 0).Store(&paths); err != nil {
+		return nil, err
+	}
+
+	var conns []Connection
+	for _, path := range paths {
+		var settings map[string]map[string]dbus.Variant
+		if err := c.conn.Object(nmName, path).Call(connectionIface+".GetSettings", 0).Store(&settings); err != nil {
+			continue
+		}
+		conn := Connection{Path: path}
+		conn.ID, _ = settings["connection"]["id"].Value().(string)
+		conn.UUID, _ = settings["connection"]["uuid"].Value().(string)
+		conn.Type, _ = settings["connection"]["type"].Value().(string)
+		ssid, _ := settings[wirelessSetting]["ssid"].Value().([]byte)
+		conn.SSID = string(ssid)
+		if conn.Type == typeLoopback {
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	slices.SortFunc(conns, func(a, b Connection) int { return cmp.Compare(a.ID, b.ID) })
+	return conns, nil
+}
+
+// accessPoints reads the access points seen by a Wi-Fi device.
+func (c *Client) accessPoints(device dbus.ObjectPath, known map[string]dbus.ObjectPath) []AccessPoint {
+	wireless, err := c.properties(device, wirelessIface)
+	if err != nil {
+		return nil
+	}
+	activePath, _ := wireless["ActiveAccessPoint"].Value().(dbus.ObjectPath)
+	paths, _ := wireless["AccessPoints"].Value().([]dbus.ObjectPath)
+
+	var aps []AccessPoint
+	for _, path := range paths {
+		props, err := c.properties(path, accessPointIface)
+		if err != nil {
+			continue
+		}
+		ssid, _ := props["Ssid"].Value().([]byte)
+		if len(ssid) == 0 {
+			// Hidden networks cannot be picked from a list.
+			continue
+		}
+		strength, _ := props["Strength"].Value().(uint8)
+		flags, _ := props["Flags"].Value().(uint32)
+		wpaFlags, _ := props["WpaFlags"].Value().(uint32)
+		rsnFlags, _ := props["RsnFlags"].Value().(uint32)
+		aps = append(aps, AccessPoint{
+			Path:       path,
+			Device:     device,
+			SSID:       string(ssid),
+			Strength:   strength,
+			Security:   security(flags, wpaFlags, rsnFlags),
+			Active:     path == activePath,
+			Connection: known[string(ssid)],
+		})
+	}
+	return aps
+}
+
+// Access point flags, see NM80211ApFlags and NM80211ApSecurityFlags.
+const (
+	apFlagPrivacy  = 0x1
+	keyMgmtPSK     = 0x100
+	keyMgmt8021X   = 0x200
+	keyMgmtSAE     = 0x400
+	keyMgmtOWE     = 0x800
+	keyMgmtOWETM   = 0x1000
+	keyMgmtEAP192  = 0x2000
+	enterpriseMgmt = keyMgmt8021X | keyMgmtEAP192
+)
+
+func security(flags, wpaFlags, rsnFlags uint32) string {
+	switch {
+	case (wpaFlags|rsnFlags)&enterpriseMgmt != 0:
+		return "802.1X"
+	case rsnFlags&keyMgmtSAE != 0:
+		return "WPA3"
+	case rsnFlags&keyMgmtPSK != 0:
+		return "WPA2"
+	case wpaFlags&keyMgmtPSK != 0:
+		return "WPA"
+	case rsnFlags&(keyMgmtOWE|keyMgmtOWETM) != 0:
+		// Enhanced open networks are encrypted but need no secret.
+		return ""
+	case flags&apFlagPrivacy != 0:
+		return "WEP"
+	}
+	return ""
+}
+
+// dedupAccessPoints keeps the active or else the strongest access point of
+// each network, strongest networks first.
+func dedupAccessPoints(aps []AccessPoint) []AccessPoint {
+	best := make(map[string]int)
+	var result []AccessPoint
+	for _, ap := range aps {
+		i, ok := best[ap.SSID]
+		if !ok {
+			best[ap.SSID] = len(result)
+			result = append(result, ap)
+			continue
+		}
+		if !result[i].Active && (ap.Active || ap.Strength > result[i].Strength) {
+			result[i] = ap
+		}
+	}
+	slices.SortStableFunc(result, func(a, b AccessPoint) int {
+		if a.Active != b.Active {
+			if a.Active {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.Strength, a.Strength)
+	})
+	return result
+}
+
+// Scan asks the Wi-Fi devices to look for access points. The results come
+// in as changes.
+func (c *Client) Scan(state State) error {
+	var firstErr error
+	for _, device := range state.Devices {
+		if device.Type != DeviceWifi || !device.Managed {
+			continue
+		}
+		err := c.conn.Object(nmName, device.Path).Call(wirelessIface+".RequestScan", 0, map[string]dbus.Variant{}).Err
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SetWirelessEnabled switches Wi-Fi on or off.
+func (c *Client) SetWirelessEnabled(enabled bool) error {
+	return c.conn.Object(nmName, nmPath).SetProperty(nmIface+".WirelessEnabled", dbus.MakeVariant(enabled))
+}
+
+// Activate activates a saved connection on a device NetworkManager picks.
+func (c *Client) Activate(conn Connection) error {
+	return c.activate(conn.Path, noObject, noObject)
+}
+
+func (c *Client) activate(conn, device, specific dbus.ObjectPath) error {
+	var active dbus.ObjectPath
+	return c.conn.Object(nmName, nmPath).Call(nmIface+".ActivateConnection", 0, conn, device, specific).Store(&active)
+}
+
+// ConnectAccessPoint connects to a Wi-Fi network. The saved connection of
+// the network is used if there is one; otherwise a new one is saved with
+// passphrase as its secret. If the passphrase is wrong or missing, the
+// secret agent is asked.
+func (c *Client) ConnectAccessPoint(ap AccessPoint, passphrase string) error {
+	if ap.Connection != "" {
+		return c.activate(ap.Connection, ap.Device, ap.Path)
+	}
+
+	settings := map[string]map[string]dbus.Variant{
+		"connection": {
+			"id":   dbus.MakeVariant(ap.SSID),
+			"type": dbus.MakeVariant(TypeWireless),
+		},
+		wirelessSetting: {
+			"ssid": dbus.MakeVariant([]byte(ap.SSID)),
+		},
+	}
+	if ap.Secured() {
+		security := map[string]dbus.Variant{}
+		switch ap.Security {
+		case "WEP":
+			security["key-mgmt"] = dbus.MakeVariant("none")
+			if passphrase != "" {
+				security["wep-key0"] = dbus.MakeVariant(passphrase)
+				// A passphrase rather than a hex or ASCII key.
+				security["wep-key-type"] = dbus.MakeVariant(uint32(2))
+			}
+		case "WPA3":
+			security["key-mgmt"] = dbus.MakeVariant("sae")
+		default:
+			security["key-mgmt"] = dbus.MakeVariant("wpa-psk")
+		}
+		if passphrase != "" && ap.Security != "WEP" {
+			security["psk"] = dbus.MakeVariant(passphrase)
+		}
+		settings[wirelessSecSetting] = security
+	}
+
+	var conn, active dbus.ObjectPath
+	return c.conn.Object(nmName, nmPath).Call(nmIface+".AddAndActivateConnection", 0, settings, ap.Device, ap.Path).Store(&conn, &active)
+}
+
+// Deactivate disconnects an active connection.
+func (c *Client) Deactivate(active ActiveConnection) error {
+	return c.conn.Object(nmName, nmPath).Call(nmIface+".DeactivateConnection", 0, active.Path).Err
+}
@@ -0,0 +1,285 @@
+// Package notification implements the desktop notification service,
+// org.freedesktop.Notifications, that applications send notifications to,
+// and keeps the notifications until they are closed.
+package notification
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/AuruTeam/desktop/watch"
+)
+
+const (
+	busName = "org.freedesktop.Notifications"
+	path    = "/org/freedesktop/Notifications"
+	iface   = "org.freedesktop.Notifications"
+
+	specVersion = "1.2"
+)
+
+// ErrRunning is returned by Start when another notification daemon owns the
+// bus name.
+var ErrRunning = errors.New("notification: another notification daemon is running")
+
+// CloseReason tells why a notification was closed.
+type CloseReason uint32
+
+// Close reasons of the NotificationClosed signal.
+const (
+	ReasonExpired   CloseReason = 1
+	ReasonDismissed CloseReason = 2
+	ReasonClosed    CloseReason = 3
+	ReasonUndefined CloseReason = 4
+)
+
+// DefaultAction is the key of the action invoked by clicking a
+// notification itself.
+const DefaultAction = "default"
+
+// Action is an action the user can take on a notification.
+type Action struct {
+	Key   string
+	Label string
+}
+
+// Notification is a notification sent by an application.
+type Notification struct {
+	ID      uint32
+	AppName string
+	AppIcon string
+	Summary string
+	Body    string
+	Actions []Action
+	Hints   map[string]dbus.Variant
+	// ExpireTimeout is how long the notification is shown. It is zero
+	// for notifications that stay until dismissed, and negative when the
+	// application leaves it to the server.
+	ExpireTimeout time.Duration
+	Timestamp     time.Time
+}
+
+// EventKind tells what happened to a notification.
+type EventKind int
+
+// Kinds of events.
+const (
+	// Added is a new notification.
+	Added EventKind = iota
+	// Replaced is a notification that was updated in place, keeping its
+	// ID.
+	Replaced
+	// Closed is a notification that was removed.
+	Closed
+)
+
+// Event reports a change of the notifications.
+type Event struct {
+	Kind         EventKind
+	Notification Notification
+	// Reason is set for Closed events.
+	Reason CloseReason
+}
+
+// Daemon is the notification service.
+type Daemon struct {
+	conn *dbus.Conn
+
+	mu            sync.Mutex
+	notifications []Notification
+	lastID        uint32
+
+	listeners watch.Listeners[Event]
+
+	// sounds is set when the sounds notifications ask for are played.
+	sounds atomic.Bool
+}
+
+// Start connects to the session bus and takes over the notification
+// service name. It returns ErrRunning if another daemon already has it.
+func Start() (*Daemon, error) {
+	// A connection of its own lets the process send notifications to
+	// itself on the shared one.
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Daemon{conn: conn}
+	if err := conn.Export(server{d}, path, iface); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reply, err := conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, ErrRunning
+	}
+	return d, nil
+}
+
+// Stop releases the service name and closes the connection.
+func (d *Daemon) Stop() error {
+	d.conn.ReleaseName(busName)
+	return d.conn.Close()
+}
+
+// SetSounds tells whether the sounds notifications ask for are played, so
+// that applications know whether to play them themselves.
+func (d *Daemon) SetSounds(played bool) {
+	d.sounds.Store(played)
+}
+
+// Notifications returns the notifications that are not closed yet, oldest
+// first.
+func (d *Daemon) Notifications() []Notification {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.notifications)
+}
+
+// Get returns the notification with the given ID, if it is not closed.
+func (d *Daemon) Get(id uint32) (Notification, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if i := d.index(id); i >= 0 {
+		return d.notifications[i], true
+	}
+	return Notification{}, false
+}
+
+// index returns the position of the notification with the given ID, or -1.
+// d.mu must be held.
+func (d *Daemon) index(id uint32) int {
+	return slices.IndexFunc(d.notifications, func(n Notification) bool { return n.ID == id })
+}
+
+// Close removes a notification and tells the application why. It does
+// nothing if the notification is already closed.
+func (d *Daemon) Close(id uint32, reason CloseReason) {
+	d.mu.Lock()
+	i := d.index(id)
+	if i < 0 {
+		d.mu.Unlock()
+		return
+	}
+	n := d.notifications[i]
+	d.notifications = slices.Delete(d.notifications, i, i+1)
+	d.mu.Unlock()
+
+	d.conn.Emit(path, iface+".NotificationClosed", id, uint32(reason))
+	d.emit(Event{Kind: Closed, Notification: n, Reason: reason})
+}
+
+// InvokeAction tells the application that the user chose the action key of
+// a notification, and closes the notification unless it is resident. token
+// is an xdg-activation token the application can use to focus its window,
+// or empty.
+func (d *Daemon) InvokeAction(id uint32, key, token string) {
+	n, ok := d.Get(id)
+	if !ok {
+		return
+	}
+	if token != "" {
+		d.conn.Emit(path, iface+".ActivationToken", id, token)
+	}
+	d.conn.Emit(path, iface+".ActionInvoked", id, key)
+	if !n.Resident() {
+		d.Close(id, ReasonDismissed)
+	}
+}
+
+// Subscribe calls fn from a background goroutine for every change of the
+// notifications, until stop is called.
+func (d *Daemon) Subscribe(fn func(Event)) (stop func()) {
+	return d.listeners.Add(fn)
+}
+
+// Watch calls changed every time a notification is added, replaced or
+// closed. It makes a Daemon a status.Source.
+func (d *Daemon) Watch(changed func()) (func(), error) {
+	return d.Subscribe(func(Event) { changed() }), nil
+}
+
+func (d *Daemon) emit(e Event) {
+	d.listeners.Notify(e)
+}
+
+// notify adds a notification, or replaces the one with the ID replacesID.
+func (d *Daemon) notify(n Notification, replacesID uint32) uint32 {
+	kind := Added
+	d.mu.Lock()
+	if i := d.index(replacesID); replacesID != 0 && i >= 0 {
+		kind = Replaced
+		n.ID = replacesID
+		d.notifications[i] = n
+	} else {
+		d.lastID++
+		// IDs are never 0, even after wrapping around.
+		if d.lastID == 0 {
+			d.lastID++
+		}
+		n.ID = d.lastID
+		d.notifications = append(d.notifications, n)
+	}
+	d.mu.Unlock()
+
+	d.emit(Event{Kind: kind, Notification: n})
+	return n.ID
+}
+
+// server has the D-Bus methods of the service, so that they are not part
+// of the API of Daemon.
+type server struct {
+	d *Daemon
+}
+
+func (s server) GetCapabilities() ([]string, *dbus.Error) {
+	capabilities := []string{"actions", "body", "icon-static", "persistence"}
+	if s.d.sounds.Load() {
+		capabilities = append(capabilities, "sound")
+	}
+	return capabilities, nil
+}
+
+func (s server) Notify(appName string, replacesID uint32, appIcon, summary, body string, actions []string, hints map[string]dbus.Variant, expireTimeout int32) (uint32, *dbus.Error) {
+	n := Notification{
+		AppName:       appName,
+		AppIcon:       appIcon,
+		Summary:       summary,
+		Body:          body,
+		Actions:       parseActions(actions),
+		Hints:         hints,
+		ExpireTimeout: time.Duration(expireTimeout) * time.Millisecond,
+		Timestamp:     time.Now(),
+	}
+	return s.d.notify(n, replacesID), nil
+}
+
+func (s server) CloseNotification(id uint32) *dbus.Error {
+	s.d.Close(id, ReasonClosed)
+	return nil
+}
+
+func (s server) GetServerInformation() (name, vendor, version, spec string, err *dbus.Error) {
+	return "auru-shell", "Auru", "1.0", specVersion, nil
+}
+
+// parseActions reads the actions of Notify, a list of keys each followed by
+// its label.
+func parseActions(list []string) []Action {
+	var actions []Action
+	for i := 0; i+1 < len(list); i += 2 {
+		actions = append(actions, Action{Key: list[i], Label: list[i+1]})
+	}
+	return actions
+}
@@ -0,0 +1,192 @@
+package notification
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Urgency is the urgency level of a notification.
+type Urgency byte
+
+// Urgency levels of the urgency hint.
+const (
+	UrgencyLow      Urgency = 0
+	UrgencyNormal   Urgency = 1
+	UrgencyCritical Urgency = 2
+)
+
+func (u Urgency) String() string {
+	switch u {
+	case UrgencyLow:
+		return "low"
+	case UrgencyCritical:
+		return "critical"
+	}
+	return "normal"
+}
+
+// Image is raw image data of the image-data hint.
+type Image struct {
+	Width, Height int
+	RowStride     int
+	HasAlpha      bool
+	BitsPerSample int
+	Channels      int
+	Data          []byte
+}
+
+// hint returns the first of the named hints that is set. Older versions of
+// the specification used other names for some hints.
+func (n Notification) hint(names ...string) (dbus.Variant, bool) {
+	for _, name := range names {
+		if v, ok := n.Hints[name]; ok && v.Value() != nil {
+			return v, true
+		}
+	}
+	return dbus.Variant{}, false
+}
+
+func (n Notification) stringHint(names ...string) string {
+	v, _ := n.hint(names...)
+	s, _ := v.Value().(string)
+	return s
+}
+
+// intHint reads an integer hint. Applications do not agree on the integer
+// type of some hints, so all of them are accepted.
+func (n Notification) intHint(names ...string) (int, bool) {
+	v, ok := n.hint(names...)
+	if !ok {
+		return 0, false
+	}
+	switch i := v.Value().(type) {
+	case byte:
+		return int(i), true
+	case int16:
+		return int(i), true
+	case uint16:
+		return int(i), true
+	case int32:
+		return int(i), true
+	case uint32:
+		return int(i), true
+	case int64:
+		return int(i), true
+	case uint64:
+		return int(i), true
+	}
+	return 0, false
+}
+
+func (n Notification) boolHint(name string) bool {
+	v, _ := n.hint(name)
+	if b, ok := v.Value().(bool); ok {
+		return b
+	}
+	i, _ := n.intHint(name)
+	return i != 0
+}
+
+// Urgency returns the urgency of the notification, normal by default.
+func (n Notification) Urgency() Urgency {
+	if u, ok := n.intHint("urgency"); ok && u >= 0 && u <= int(UrgencyCritical) {
+		return Urgency(u)
+	}
+	return UrgencyNormal
+}
+
+// Category returns the type of the notification, such as email.arrived.
+func (n Notification) Category() string {
+	return n.stringHint("category")
+}
+
+// DesktopEntry returns the desktop file ID of the application.
+func (n Notification) DesktopEntry() string {
+	return n.stringHint("desktop-entry")
+}
+
+// Transient tells whether the notification is not to be kept once its popup
+// is gone.
+func (n Notification) Transient() bool {
+	return n.boolHint("transient")
+}
+
+// Resident tells whether the notification stays after one of its actions
+// was invoked.
+func (n Notification) Resident() bool {
+	return n.boolHint("resident")
+}
+
+// Progress returns the progress in percent the notification shows, if it
+// has one.
+func (n Notification) Progress() (int, bool) {
+	value, ok := n.intHint("value")
+	return min(max(value, 0), 100), ok
+}
+
+// ImagePath returns the image of the notification as a file path or an
+// icon name. file:// URIs are turned into paths.
+func (n Notification) ImagePath() string {
+	return filePath(n.stringHint("image-path", "image_path"))
+}
+
+// Image returns the raw image of the notification, if it has one.
+func (n Notification) Image() (Image, bool) {
+	v, ok := n.hint("image-data", "image_data", "icon_data")
+	if !ok {
+		return Image{}, false
+	}
+	var raw struct {
+		Width, Height, RowStride int32
+		HasAlpha                 bool
+		BitsPerSample, Channels  int32
+		Data                     []byte
+	}
+	// GdkPixbuf, like most image libraries, only takes 8 bit RGB(A).
+	if v.Store(&raw) != nil || raw.Width <= 0 || raw.Height <= 0 || raw.BitsPerSample != 8 || (raw.Channels != 3 && raw.Channels != 4) {
+		return Image{}, false
+	}
+	img := Image{
+		Width:         int(raw.Width),
+		Height:        int(raw.Height),
+		RowStride:     int(raw.RowStride),
+		HasAlpha:      raw.HasAlpha,
+		BitsPerSample: int(raw.BitsPerSample),
+		Channels:      int(raw.Channels),
+		Data:          raw.Data,
+	}
+	rowBytes := img.Width * img.Channels * img.BitsPerSample / 8
+	if img.RowStride < rowBytes || len(img.Data) < (img.Height-1)*img.RowStride+rowBytes {
+		return Image{}, false
+	}
+	return img, true
+}
+
+// SoundFile returns the path of the sound to play for the notification.
+func (n Notification) SoundFile() string {
+	return filePath(n.stringHint("sound-file"))
+}
+
+// SoundName returns the name of the sound to play for the notification, in
+// the freedesktop sound theme naming.
+func (n Notification) SoundName() string {
+	return n.stringHint("sound-name")
+}
+
+// SuppressSound tells whether the notification is to be shown silently.
+func (n Notification) SuppressSound() bool {
+	return n.boolHint("suppress-sound")
+}
+
+// filePath turns a file:// URI into a path and leaves other values alone.
+func filePath(s string) string {
+	if !strings.HasPrefix(s, "file://") {
+		return s
+	}
+	if u, err := url.Parse(s); err == nil {
+		return u.Path
+	}
+	return s
+}
@@ -0,0 +1,28 @@
+package power
+
+const (
+	loginName         = "org.freedesktop.login1"
+	loginPath         = "/org/freedesktop/login1"
+	loginManagerIface = "org.freedesktop.login1.Manager"
+)
+
+// Suspend suspends the computer through logind.
+func (c *Client) Suspend() error {
+	return c.login("Suspend")
+}
+
+// Hibernate hibernates the computer through logind.
+func (c *Client) Hibernate() error {
+	return c.login("Hibernate")
+}
+
+// PowerOff shuts the computer down through logind.
+func (c *Client) PowerOff() error {
+	return c.login("PowerOff")
+}
+
+func (c *Client) login(method string) error {
+	// Not interactive: there is nobody to ask for a password when the
+	// battery runs out.
+	return c.conn.Object(loginName, loginPath).Call(loginManagerIface+"."+method, 0, false).Err
+}
@@ -0,0 +1,307 @@
+// Package power reads batteries from UPower and switches power profiles
+// through power-profiles-daemon, both over the system D-Bus.
+package power
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/AuruTeam/desktop/watch"
+)
+
+const (
+	upowerName      = "org.freedesktop.UPower"
+	upowerPath      = "/org/freedesktop/UPower"
+	upowerIface     = "org.freedesktop.UPower"
+	deviceIface     = "org.freedesktop.UPower.Device"
+	displayPath     = "/org/freedesktop/UPower/devices/DisplayDevice"
+	propertiesIface = "org.freedesktop.DBus.Properties"
+)
+
+// ErrNoProfiles is returned when power-profiles-daemon is not running.
+var ErrNoProfiles = errors.New("power: power-profiles-daemon is not available")
+
+// profileServices are the names power-profiles-daemon is reachable under,
+// the current one first.
+var profileServices = []struct {
+	name  string
+	path  dbus.ObjectPath
+	iface string
+}{
+	{"org.freedesktop.UPower.PowerProfiles", "/org/freedesktop/UPower/PowerProfiles", "org.freedesktop.UPower.PowerProfiles"},
+	{"net.hadess.PowerProfiles", "/net/hadess/PowerProfiles", "net.hadess.PowerProfiles"},
+}
+
+// Power profiles.
+const (
+	ProfilePowerSaver  = "power-saver"
+	ProfileBalanced    = "balanced"
+	ProfilePerformance = "performance"
+)
+
+// Kind is the kind of a power device.
+type Kind uint32
+
+// Device kinds, as numbered by UPower.
+const (
+	KindUnknown Kind = iota
+	KindLinePower
+	KindBattery
+	KindUPS
+	KindMonitor
+	KindMouse
+	KindKeyboard
+	KindPDA
+	KindPhone
+	KindMediaPlayer
+	KindTablet
+	KindComputer
+	KindGamingInput
+	KindPen
+	KindTouchpad
+	KindModem
+	KindNetwork
+	KindHeadset
+	KindSpeakers
+	KindHeadphones
+)
+
+var kindNames = map[Kind]string{
+	KindBattery:     "Battery",
+	KindUPS:         "UPS",
+	KindMouse:       "Mouse",
+	KindKeyboard:    "Keyboard",
+	KindPhone:       "Phone",
+	KindMediaPlayer: "Media player",
+	KindTablet:      "Tablet",
+	KindGamingInput: "Game controller",
+	KindPen:         "Pen",
+	KindTouchpad:    "Touchpad",
+	KindHeadset:     "Headset",
+	KindSpeakers:    "Speakers",
+	KindHeadphones:  "Headphones",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "Device"
+}
+
+// State is the charging state of a battery.
+type State uint32
+
+// Battery states, as numbered by UPower.
+const (
+	StateUnknown State = iota
+	StateCharging
+	StateDischarging
+	StateEmpty
+	StateFullyCharged
+	StatePendingCharge
+	StatePendingDischarge
+)
+
+func (s State) String() string {
+	switch s {
+	case StateCharging:
+		return "Charging"
+	case StateDischarging:
+		return "Discharging"
+	case StateEmpty:
+		return "Empty"
+	case StateFullyCharged:
+		return "Fully charged"
+	case StatePendingCharge, StatePendingDischarge:
+		return "Not charging"
+	}
+	return "Unknown"
+}
+
+// Device is a battery of the computer or of a peripheral.
+type Device struct {
+	Path  dbus.ObjectPath
+	Kind  Kind
+	State State
+	// Percentage is the charge, from 0 to 100.
+	Percentage  float64
+	TimeToEmpty time.Duration
+	TimeToFull  time.Duration
+	Model       string
+	Vendor      string
+	IconName    string
+	// PowerSupply is set for batteries that power the computer.
+	PowerSupply bool
+}
+
+// Name returns the vendor and model of the device, or its kind.
+func (d Device) Name() string {
+	if name := strings.TrimSpace(d.Vendor + " " + d.Model); name != "" {
+		return name
+	}
+	return d.Kind.String()
+}
+
+// Profiles is the state of power-profiles-daemon.
+type Profiles struct {
+	// Available lists the profiles of the machine, usually power-saver,
+	// balanced and performance.
+	Available []string
+	Active    string
+	// Degraded tells why the performance profile is held back, such as
+	// "lap-detected" or "high-operating-temperature".
+	Degraded string
+}
+
+// Client is a connection to UPower and power-profiles-daemon.
+type Client struct {
+	conn *dbus.Conn
+
+	listeners watch.Listeners[struct{}]
+
+	mu       sync.Mutex
+	watching bool
+}
+
+// Connect connects to the system bus.
+func Connect() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// watchDelay is how long Watch waits for further changes before reporting
+// them, as UPower sends a signal for every property of a device.
+const watchDelay = 200 * time.Millisecond
+
+// Watch calls changed every time a battery or the power profile changes,
+// until stop is called. It makes a Client a status.Source.
+func (c *Client) Watch(changed func()) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.watching {
+		matches := [][]dbus.MatchOption{
+			{dbus.WithMatchSender(upowerName)},
+		}
+		for _, s := range profileServices {
+			matches = append(matches, []dbus.MatchOption{dbus.WithMatchSender(s.name)})
+		}
+		for _, match := range matches {
+			if err := c.conn.AddMatchSignal(match...); err != nil {
+				return nil, err
+			}
+		}
+		signals := make(chan *dbus.Signal, 16)
+		c.conn.Signal(signals)
+		notify := watch.Coalesce(watchDelay, func() { c.listeners.Notify(struct{}{}) })
+		go func() {
+			// The channel is closed together with the connection.
+			for range signals {
+				notify()
+			}
+		}()
+		c.watching = true
+	}
+
+	return c.listeners.Add(func(struct{}) { changed() }), nil
+}
+
+// Display returns the display device of UPower, which combines all
+// batteries that power the computer.
+func (c *Client) Display() (Device, error) {
+	return c.device(displayPath)
+}
+
+// Devices returns the batteries of the computer and of its peripherals.
+func (c *Client) Devices() ([]Device, error) {
+	var paths []dbus.ObjectPath
+	if err := c.conn.Object(upowerName, upowerPath).Call(upowerIface+".EnumerateDevices", 0).Store(&paths); err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	for _, path := range paths {
+		device, err := c.device(path)
+		if err != nil || device.Kind == KindLinePower || device.Kind == KindUnknown {
+			continue
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+func (c *Client) device(path dbus.ObjectPath) (Device, error) {
+	var props map[string]dbus.Variant
+	err := c.conn.Object(upowerName, path).Call(propertiesIface+".GetAll", 0, deviceIface).Store(&props)
+	if err != nil {
+		return Device{}, err
+	}
+
+	d := Device{Path: path}
+	kind, _ := props["Type"].Value().(uint32)
+	d.Kind = Kind(kind)
+	state, _ := props["State"].Value().(uint32)
+	d.State = State(state)
+	d.Percentage, _ = props["Percentage"].Value().(float64)
+	toEmpty, _ := props["TimeToEmpty"].Value().(int64)
+	d.TimeToEmpty = time.Duration(toEmpty) * time.Second
+	toFull, _ := props["TimeToFull"].Value().(int64)
+	d.TimeToFull = time.Duration(toFull) * time.Second
+	d.Model, _ = props["Model"].Value().(string)
+	d.Vendor, _ = props["Vendor"].Value().(string)
+	d.IconName, _ = props["IconName"].Value().(string)
+	d.PowerSupply, _ = props["PowerSupply"].Value().(bool)
+	return d, nil
+}
+
+// Profiles reads the power profiles. It returns ErrNoProfiles if
+// power-profiles-daemon is not running.
+func (c *Client) Profiles() (Profiles, error) {
+	for _, s := range profileServices {
+		var props map[string]dbus.Variant
+		err := c.conn.Object(s.name, s.path).Call(propertiesIface+".GetAll", 0, s.iface).Store(&props)
+		if err != nil {
+			continue
+		}
+
+		var p Profiles
+		p.Active, _ = props["ActiveProfile"].Value().(string)
+		p.Degraded, _ = props["PerformanceDegraded"].Value().(string)
+		var profiles []map[string]dbus.Variant
+		if v, ok := props["Profiles"]; ok && v.Store(&profiles) == nil {
+			for _, profile := range profiles {
+				if name, ok := profile["Profile"].Value().(string); ok {
+					p.Available = append(p.Available, name)
+				}
+			}
+		}
+		return p, nil
+	}
+	return Profiles{}, ErrNoProfiles
+}
+
+// SetProfile switches to a power profile.
+func (c *Client) SetProfile(profile string) error {
+	for _, s := range profileServices {
+		err := c.conn.Object(s.name, s.path).SetProperty(s.iface+".ActiveProfile", dbus.MakeVariant(profile))
+		var dbusErr dbus.Error
+		if errors.As(err, &dbusErr) && dbusErr.Name == "org.freedesktop.DBus.Error.ServiceUnknown" {
+			continue
+		}
+		return err
+	}
+	return ErrNoProfiles
+}
@@ -0,0 +1,92 @@
+package shell
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/AuruTeam/desktop/apps"
+	"github.com/AuruTeam/libxdg-go/desktopFiles"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// fallbackAppIcon is shown for windows whose application is unknown.
+const fallbackAppIcon = "application-x-executable"
+
+// setAppIcon shows the icon of the application with the given app_id in img.
+func setAppIcon(img *gtk.Image, appID string, size int) {
+	icon := fallbackAppIcon
+	if entry, ok := apps.Lookup(appID); ok && entry.Icon != "" {
+		icon = entry.Icon
+	}
+
+	if filepath.IsAbs(icon) {
+		if pixbuf, err := gdk.PixbufNewFromFileAtScale(icon, size, size, true); err == nil {
+			img.SetFromPixbuf(pixbuf)
+			return
+		}
+		icon = fallbackAppIcon
+	}
+
+	img.SetFromIconName(icon, gtk.ICON_SIZE_BUTTON)
+	img.SetPixelSize(size)
+}
+
+// appKey returns the desktop file ID of the application with the given
+// app_id, or the app_id itself if the application is unknown. Windows and
+// pinned launchers with the same key share a taskbar button.
+func appKey(appID string) string {
+	if entry, ok := apps.Lookup(appID); ok {
+		return entry.ID
+	}
+	return appID
+}
+
+// desktopFileFor returns the desktop file of the application with the given
+// desktop file ID, as used by the main menu and for launching. libxdg-go does
+// not tell which file a desktop file was read from, so it is matched with the
+// installed entry by its Exec line, which, unlike the name, is not
+// translated.
+func desktopFileFor(id string) (desktopFiles.DesktopFile, bool) {
+	entry, ok := apps.Lookup(id)
+	if !ok || entry.ID != id {
+		return desktopFiles.DesktopFile{}, false
+	}
+
+	files, err := desktopFiles.ListAllApplications()
+	if err != nil {
+		log.Println("Error listing applications:", err)
+		return desktopFiles.DesktopFile{}, false
+	}
+	for _, file := range files {
+		if file.Exec == entry.Exec {
+			return file, true
+		}
+	}
+	return desktopFiles.DesktopFile{}, false
+}
+
+// appIDFor returns the desktop file ID of an application listed by the main
+// menu.
+func appIDFor(file desktopFiles.DesktopFile) (string, bool) {
+	for _, entry := range apps.All() {
+		if entry.Exec == file.Exec {
+			return entry.ID, true
+		}
+	}
+	return "", false
+}
+
+// launchApp starts the application with the given desktop file ID.
+func launchApp(id string) {
+	go func() {
+		file, ok := desktopFileFor(id)
+		if !ok {
+			log.Println("Cannot launch unknown application", id)
+			return
+		}
+		if err := desktopFiles.ExecuteDesktopFile(file, []string{}, ""); err != nil {
+			log.Println("Error launching", file.Name+":", err)
+		}
+	}()
+}
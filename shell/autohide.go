@@ -0,0 +1,215 @@
+package shell
+
+import (
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/toplevel"
+	"github.com/dlasky/gotk3-layershell/layershell"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// triggerSize is the thickness of the strip that reveals a hidden bar.
+const triggerSize = 2
+
+// autoHide slides a bar out of the screen and reveals it again when the
+// pointer hits a thin trigger strip at the screen edge. While hidden, the
+// bar gives its exclusive zone back to the windows.
+type autoHide struct {
+	win      *gtk.Window
+	revealer *gtk.Revealer
+	trigger  *gtk.Window
+	mon      *gdk.Monitor
+	mode     string
+	delay    uint
+
+	hovered bool
+	timer   glib.SourceHandle
+
+	// covering holds the windows that cover the bar, as told by
+	// toplevel.Toplevel.Covers for the output of its monitor.
+	covering map[*toplevel.Handle]bool
+}
+
+// setupAutoHide puts content into the bar window win and hides it as
+// configured by cfg.AutoHide.
+func setupAutoHide(win *gtk.Window, content gtk.IWidget, mon *gdk.Monitor, cfg config.Bar, layout barLayout) {
+	ah := &autoHide{
+		win:      win,
+		mon:      mon,
+		mode:     cfg.AutoHide,
+		delay:    uint(cfg.HideDelay),
+		covering: make(map[*toplevel.Handle]bool),
+	}
+
+	// The revealer is kept at its natural size on the screen edge, so its
+	// content slides towards the edge as it shrinks.
+	ah.revealer, _ = gtk.RevealerNew()
+	switch layout.edge {
+	case layershell.LAYER_SHELL_EDGE_TOP:
+		ah.revealer.SetTransitionType(gtk.REVEALER_TRANSITION_TYPE_SLIDE_DOWN)
+		ah.revealer.SetVAlign(gtk.ALIGN_START)
+	case layershell.LAYER_SHELL_EDGE_LEFT:
+		ah.revealer.SetTransitionType(gtk.REVEALER_TRANSITION_TYPE_SLIDE_RIGHT)
+		ah.revealer.SetHAlign(gtk.ALIGN_START)
+	case layershell.LAYER_SHELL_EDGE_RIGHT:
+		ah.revealer.SetTransitionType(gtk.REVEALER_TRANSITION_TYPE_SLIDE_LEFT)
+		ah.revealer.SetHAlign(gtk.ALIGN_END)
+	default:
+		ah.revealer.SetTransitionType(gtk.REVEALER_TRANSITION_TYPE_SLIDE_UP)
+		ah.revealer.SetVAlign(gtk.ALIGN_END)
+	}
+	ah.revealer.SetRevealChild(true)
+	ah.revealer.Add(content)
+	ah.revealer.Connect("notify::child-revealed", func() {
+		// Unmap the bar once it has slid out, so it does not take any
+		// input or space on the screen.
+		if !ah.revealer.GetRevealChild() && !ah.revealer.GetChildRevealed() {
+			ah.win.Hide()
+			ah.trigger.ShowAll()
+		}
+	})
+	win.Add(ah.revealer)
+
+	ah.trigger = createTrigger(mon, layout)
+	ah.trigger.Connect("enter-notify-event", func() bool {
+		ah.reveal()
+		// The pointer may still miss a bar with margins, so the bar hides
+		// again unless it gets entered.
+		ah.scheduleHide()
+		return false
+	})
+
+	win.AddEvents(int(gdk.ENTER_NOTIFY_MASK | gdk.LEAVE_NOTIFY_MASK))
+	win.Connect("enter-notify-event", func(_ *gtk.Window, ev *gdk.Event) bool {
+		if gdk.EventCrossingNewFromEvent(ev).Detail() == gdk.NOTIFY_INFERIOR {
+			return false
+		}
+		ah.hovered = true
+		ah.cancelHide()
+		return false
+	})
+	win.Connect("leave-notify-event", func(_ *gtk.Window, ev *gdk.Event) bool {
+		crossing := gdk.EventCrossingNewFromEvent(ev)
+		// Moving into a child widget or into a menu of the bar does not
+		// leave the bar.
+		if crossing.Detail() == gdk.NOTIFY_INFERIOR || crossing.Mode() == gdk.CROSSING_GRAB {
+			return false
+		}
+		ah.hovered = false
+		ah.scheduleHide()
+		return false
+	})
+
+	unsubscribe := func() {}
+	if ah.mode == config.AutoHideOverlap {
+		unsubscribe = getToplevels().subscribe(ah.handle)
+	}
+	win.Connect("destroy", func() {
+		unsubscribe()
+		ah.cancelHide()
+		ah.trigger.Destroy()
+	})
+
+	ah.update()
+}
+
+// createTrigger creates the strip along the whole edge of the bar that
+// reveals it when the pointer enters it.
+func createTrigger(mon *gdk.Monitor, layout barLayout) *gtk.Window {
+	win, _ := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	win.SetTitle("Main Bar Trigger")
+	win.SetDecorated(false)
+	win.SetTypeHint(gdk.WINDOW_TYPE_HINT_DOCK)
+	sc, _ := win.GetStyleContext()
+	sc.AddClass("bar-trigger")
+	if visual, err := win.GetScreen().GetRGBAVisual(); err == nil {
+		win.SetVisual(visual)
+	}
+
+	layershell.InitForWindow(win)
+	layershell.SetNamespace(win, "miracleos")
+	layershell.SetLayer(win, layershell.LAYER_SHELL_LAYER_TOP)
+	layershell.SetAnchor(win, layout.edge, true)
+	if layout.orientation == gtk.ORIENTATION_VERTICAL {
+		layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_TOP, true)
+		layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_BOTTOM, true)
+		win.SetSizeRequest(triggerSize, -1)
+	} else {
+		layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_LEFT, true)
+		layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_RIGHT, true)
+		win.SetSizeRequest(-1, triggerSize)
+	}
+	// Stay on the very edge of the screen, even next to other panels.
+	layershell.SetExclusiveZone(win, -1)
+	layershell.SetKeyboardMode(win, layershell.LAYER_SHELL_KEYBOARD_MODE_NONE)
+	layershell.SetMonitor(win, mon)
+
+	win.AddEvents(int(gdk.ENTER_NOTIFY_MASK))
+	return win
+}
+
+func (ah *autoHide) handle(e toplevel.Event) {
+	t := e.Toplevel
+	o, ok := monitorOutput(ah.mon)
+	if e.Kind != toplevel.Closed && ok && t.Covers(o) {
+		ah.covering[t.Handle] = true
+	} else {
+		delete(ah.covering, t.Handle)
+	}
+	ah.update()
+}
+
+// shouldHide reports whether the bar is to be hidden now.
+func (ah *autoHide) shouldHide() bool {
+	if ah.hovered {
+		return false
+	}
+	return ah.mode == config.AutoHideAlways || len(ah.covering) > 0
+}
+
+// update hides or reveals the bar after its state changed.
+func (ah *autoHide) update() {
+	if ah.shouldHide() {
+		ah.scheduleHide()
+	} else {
+		ah.reveal()
+	}
+}
+
+func (ah *autoHide) scheduleHide() {
+	ah.cancelHide()
+	if !ah.shouldHide() || !ah.revealer.GetRevealChild() {
+		return
+	}
+	ah.timer = glib.TimeoutAdd(ah.delay, func() bool {
+		ah.timer = 0
+		if ah.shouldHide() {
+			ah.hide()
+		}
+		return false
+	})
+}
+
+func (ah *autoHide) cancelHide() {
+	if ah.timer != 0 {
+		glib.SourceRemove(ah.timer)
+		ah.timer = 0
+	}
+}
+
+func (ah *autoHide) hide() {
+	layershell.SetExclusiveZone(ah.win, 0)
+	ah.revealer.SetRevealChild(false)
+}
+
+func (ah *autoHide) reveal() {
+	ah.cancelHide()
+	if ah.revealer.GetRevealChild() {
+		return
+	}
+	ah.trigger.Hide()
+	ah.win.Show()
+	layershell.AutoExclusiveZoneEnable(ah.win)
+	ah.revealer.SetRevealChild(true)
+}
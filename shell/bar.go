@@ -0,0 +1,302 @@
+package shell
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/AuruTeam/desktop/audio"
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/network"
+	"github.com/AuruTeam/desktop/notification"
+	"github.com/AuruTeam/desktop/power"
+	"github.com/AuruTeam/desktop/status"
+	"github.com/AuruTeam/desktoplib/batteryHandler"
+	"github.com/AuruTeam/desktoplib/networkManagerHandler"
+	"github.com/AuruTeam/desktoplib/volumeHandler"
+	"github.com/dlasky/gotk3-layershell/layershell"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// barLayout tells the widgets of a bar which way it runs.
+type barLayout struct {
+	edge        layershell.LayerShellEdgeFlags
+	orientation gtk.Orientation
+	// popover is the side popovers of the bar open on, away from its edge.
+	popover gtk.PositionType
+}
+
+func newBarLayout(cfg config.Bar) barLayout {
+	switch cfg.Position {
+	case config.PositionTop:
+		return barLayout{layershell.LAYER_SHELL_EDGE_TOP, gtk.ORIENTATION_HORIZONTAL, gtk.POS_BOTTOM}
+	case config.PositionLeft:
+		return barLayout{layershell.LAYER_SHELL_EDGE_LEFT, gtk.ORIENTATION_VERTICAL, gtk.POS_RIGHT}
+	case config.PositionRight:
+		return barLayout{layershell.LAYER_SHELL_EDGE_RIGHT, gtk.ORIENTATION_VERTICAL, gtk.POS_LEFT}
+	}
+	return barLayout{layershell.LAYER_SHELL_EDGE_BOTTOM, gtk.ORIENTATION_HORIZONTAL, gtk.POS_TOP}
+}
+
+// align sets the alignment of w along the bar.
+func (l barLayout) align(w gtk.IWidget, align gtk.Align) {
+	if l.orientation == gtk.ORIENTATION_VERTICAL {
+		w.ToWidget().SetVAlign(align)
+	} else {
+		w.ToWidget().SetHAlign(align)
+	}
+}
+
+func newStatusModule(ctx ModuleContext, _ config.Module) (*Module, error) {
+	statusBox, _ := gtk.BoxNew(ctx.Orientation(), 0)
+	sc, _ := statusBox.GetStyleContext()
+	sc.AddClass("status-icons-wrapper")
+	m := &Module{Widget: statusBox}
+
+	if _, err := volumeHandler.GetAudioIcon(); err == nil {
+		if mixer, err := audio.Open(); err == nil {
+			volume := newVolumeControl(ctx, mixer)
+			m.Sources = append(m.Sources, ModuleSource{Status: mixer, Update: volume.update})
+			statusBox.PackStart(volume.button, false, false, 0)
+		} else {
+			// Without a connection to the sound server the icon can
+			// only show the volume.
+			log.Println("Volume mixer disabled:", err)
+			volumeImage, _ := gtk.ImageNew()
+			sc, _ = volumeImage.GetStyleContext()
+			sc.AddClass("sound")
+			m.Sources = append(m.Sources, ModuleSource{Status: status.Audio(), Update: func() {
+				newVolumeIcon, err := volumeHandler.GetAudioIcon()
+				if err == nil {
+					volumeImage.SetFromIconName(newVolumeIcon, gtk.ICON_SIZE_BUTTON)
+				}
+			}})
+
+			statusBox.PackStart(volumeImage, false, false, 0)
+		}
+	}
+
+	if _, err := networkManagerHandler.GetNetworkIcon(); err == nil {
+		if client, err := network.Connect(); err == nil {
+			control := newNetworkControl(ctx, client)
+			m.Sources = append(m.Sources, ModuleSource{Status: client, Update: control.update})
+			statusBox.PackStart(control.button, false, false, 0)
+		} else {
+			log.Println("Network menu disabled:", err)
+			networkImage, _ := gtk.ImageNew()
+			sc, _ = networkImage.GetStyleContext()
+			sc.AddClass("network")
+			m.Sources = append(m.Sources, ModuleSource{Status: status.Network(), Update: func() {
+				networkIcon, err := networkManagerHandler.GetNetworkIcon()
+				if err == nil {
+					networkImage.SetFromIconName(networkIcon, gtk.ICON_SIZE_BUTTON)
+				}
+			}})
+
+			statusBox.PackStart(networkImage, false, false, 0)
+		}
+	}
+
+	if batteryHandler.IsBattery() {
+		if client, err := power.Connect(); err == nil {
+			control := newBatteryControl(ctx, client)
+			m.Sources = append(m.Sources, ModuleSource{Status: client, Update: control.update})
+			statusBox.PackStart(control.button, false, false, 0)
+		} else {
+			log.Println("Battery menu disabled:", err)
+			batteryImage, _ := gtk.ImageNew()
+			sc, _ = batteryImage.GetStyleContext()
+			sc.AddClass("power")
+			m.Sources = append(m.Sources, ModuleSource{Status: status.Battery(), Update: func() {
+				if batteryHandler.IsBattery() {
+					batteryImage.SetFromIconName(batteryHandler.GetBatteryIcon(), gtk.ICON_SIZE_BUTTON)
+				}
+			}})
+
+			statusBox.PackStart(batteryImage, false, false, 0)
+		}
+	}
+
+	return m, nil
+}
+
+func newNotificationsModule(ctx ModuleContext, _ config.Module) (*Module, error) {
+	nDaemon := ctx.Notifications
+	if nDaemon == nil {
+		return nil, errors.New("no notification daemon")
+	}
+
+	notificationButton, _ := gtk.ButtonNew()
+	notificationBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 0)
+	sc, _ := notificationBox.GetStyleContext()
+	sc.AddClass("notification-bell-wrapper")
+
+	notificationBar := CreateNotificationBar(nDaemon)
+	notificationButton.Connect("clicked", func() {
+		if notificationBar.IsVisible() {
+			notificationBar.Hide()
+		} else {
+			if listedNotifications(nDaemon) != 0 {
+				notificationBar.ShowAll()
+			}
+
+		}
+	})
+	notificationButton.Connect("destroy", notificationBar.Destroy)
+
+	ntStack, _ := gtk.StackNew()
+
+	notificationImage, _ := gtk.ImageNewFromIconName("preferences-system-notifications-symbolic", gtk.ICON_SIZE_BUTTON)
+	sc, _ = notificationImage.GetStyleContext()
+	sc.AddClass("notification-bell")
+
+	notificationText, _ := gtk.LabelNew(strconv.Itoa(listedNotifications(nDaemon)))
+	sc, _ = notificationText.GetStyleContext()
+	sc.AddClass("h2")
+
+	ntStack.Add(notificationImage)
+	ntStack.Add(notificationText)
+
+	notificationBox.PackStart(ntStack, false, false, 0)
+	notificationButton.Add(notificationBox)
+
+	return &Module{Widget: notificationButton, Sources: []ModuleSource{{
+		Status: nDaemon,
+		Update: func() {
+			count := listedNotifications(nDaemon)
+			notificationText.SetText(strconv.Itoa(count))
+			if count == 0 {
+				ntStack.SetVisibleChild(notificationImage)
+				ntStack.SetTransitionType(gtk.STACK_TRANSITION_TYPE_SLIDE_LEFT)
+			} else {
+				ntStack.SetVisibleChild(notificationText)
+				ntStack.SetTransitionType(gtk.STACK_TRANSITION_TYPE_SLIDE_RIGHT)
+			}
+		},
+	}}}, nil
+}
+
+func newLauncherModule(ctx ModuleContext, _ config.Module) (*Module, error) {
+	box, _ := gtk.BoxNew(ctx.Orientation(), 10)
+
+	desktopImage, _ := gtk.ImageNewFromIconName("preferences-system-windows-symbolic", gtk.ICON_SIZE_LARGE_TOOLBAR)
+	searchImage, _ := gtk.ImageNewFromIconName("system-search-symbolic", gtk.ICON_SIZE_LARGE_TOOLBAR)
+	customIcon, _ := gtk.ImageNewFromFile("images/pp.png")
+	customButton, _ := gtk.ButtonNew()
+	customButton.Add(customIcon)
+
+	mm := createMainMenu(ctx.Monitor, ctx.layout.edge)
+	box.Connect("destroy", mm.Destroy)
+
+	customButton.Connect("clicked", func() {
+		if mm.IsVisible() {
+			mm.Hide()
+		} else {
+			mm.ShowAll()
+		}
+		//box.Hide()
+	})
+
+	box.PackStart(desktopImage, false, false, 0)
+	box.PackStart(customButton, false, false, 0)
+	box.PackStart(searchImage, false, false, 0)
+
+	return &Module{Widget: box}, nil
+}
+
+func newTaskbarModule(ctx ModuleContext, _ config.Module) (*Module, error) {
+	var mon *gdk.Monitor
+	if ctx.Bar.TaskbarPerOutput {
+		mon = ctx.Monitor
+	}
+	return &Module{Widget: createWorkspaces(mon, ctx.layout)}, nil
+}
+
+// CreateBar creates the main bar with the taskbar, the main menu button and
+// the status area on the monitor mon, or on the first monitor if mon is nil.
+func CreateBar(nDaemon *notification.Daemon, mon *gdk.Monitor) *gtk.Window {
+	cfg, err := config.LoadBar()
+	if err != nil {
+		log.Println("Error loading bar configuration:", err)
+	}
+	if mon == nil {
+		disp, _ := gdk.DisplayGetDefault()
+		mon, _ = disp.GetMonitor(0)
+	}
+	return createBar(nDaemon, mon, cfg)
+}
+
+func createBar(nDaemon *notification.Daemon, mon *gdk.Monitor, cfg config.Bar) *gtk.Window {
+	win, _ := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	win.SetTitle("Main Bar")
+	win.SetDecorated(false)
+	win.SetResizable(false)
+	win.SetTypeHint(gdk.WINDOW_TYPE_HINT_DOCK)
+
+	layout := newBarLayout(cfg)
+
+	layershell.InitForWindow(win)
+	layershell.SetNamespace(win, "miracleos")
+	layershell.SetLayer(win, layershell.LAYER_SHELL_LAYER_TOP)
+
+	// An attached bar spans its whole edge; a floating one is centered on
+	// it and only as long as its content.
+	layershell.SetAnchor(win, layout.edge, true)
+	if !cfg.Floating {
+		if layout.orientation == gtk.ORIENTATION_VERTICAL {
+			layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_TOP, true)
+			layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_BOTTOM, true)
+		} else {
+			layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_LEFT, true)
+			layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_RIGHT, true)
+		}
+	}
+
+	layershell.SetMargin(win, layershell.LAYER_SHELL_EDGE_TOP, cfg.Margins.Top)
+	layershell.SetMargin(win, layershell.LAYER_SHELL_EDGE_BOTTOM, cfg.Margins.Bottom)
+	layershell.SetMargin(win, layershell.LAYER_SHELL_EDGE_LEFT, cfg.Margins.Left)
+	layershell.SetMargin(win, layershell.LAYER_SHELL_EDGE_RIGHT, cfg.Margins.Right)
+
+	// The exclusive zone follows the size of the bar and its margin.
+	layershell.AutoExclusiveZoneEnable(win)
+	layershell.SetKeyboardMode(win, layershell.LAYER_SHELL_KEYBOARD_MODE_NONE)
+	layershell.SetMonitor(win, mon)
+
+	if layout.orientation == gtk.ORIENTATION_VERTICAL {
+		win.SetSizeRequest(cfg.Size, -1)
+	} else {
+		win.SetSizeRequest(-1, cfg.Size)
+	}
+
+	box, _ := gtk.BoxNew(layout.orientation, 10)
+	sc, _ := box.GetStyleContext()
+	sc.AddClass("bar")
+	sc.AddClass(cfg.Position)
+	if layout.orientation == gtk.ORIENTATION_VERTICAL {
+		sc.AddClass("vertical")
+	}
+	if cfg.Floating {
+		sc.AddClass("floating")
+	}
+
+	ctx := ModuleContext{
+		Monitor:       mon,
+		Notifications: nDaemon,
+		Bar:           cfg,
+		layout:        layout,
+	}
+	start := createSection(ctx, cfg.Layout.Left, gtk.ALIGN_START, "bar-left")
+	center := createSection(ctx, cfg.Layout.Center, gtk.ALIGN_CENTER, "bar-center")
+	end := createSection(ctx, cfg.Layout.Right, gtk.ALIGN_END, "bar-right", "sidestuff")
+	box.PackStart(start, false, false, 0)
+	box.SetCenterWidget(center)
+	box.PackEnd(end, false, false, 0)
+
+	if cfg.AutoHide == config.AutoHideNever {
+		win.Add(box)
+	} else {
+		setupAutoHide(win, box, mon, cfg, layout)
+	}
+	return win
+}
@@ -0,0 +1,180 @@
+package shell
+
+import (
+	"log"
+	"reflect"
+	"slices"
+	"sync/atomic"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/notification"
+	"github.com/AuruTeam/desktop/toplevel"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// barSet keeps one bar on every monitor selected by the configuration.
+type barSet struct {
+	nDaemon *notification.Daemon
+	disp    *gdk.Display
+	cfg     config.Bar
+	bars    map[uintptr]*gtk.Window
+}
+
+// ShowBars shows a bar on every monitor selected by the bar configuration
+// and creates and destroys bars as monitors are plugged in and out or the
+// configuration changes.
+func ShowBars(nDaemon *notification.Daemon) {
+	disp, err := gdk.DisplayGetDefault()
+	if err != nil {
+		log.Println("Failed to get default display:", err)
+		return
+	}
+
+	cfg, err := config.LoadBar()
+	if err != nil {
+		log.Println("Error loading bar configuration:", err)
+	}
+
+	bs := &barSet{
+		nDaemon: nDaemon,
+		disp:    disp,
+		cfg:     cfg,
+		bars:    make(map[uintptr]*gtk.Window),
+	}
+	bs.sync()
+
+	// GdkMonitor has no registered Go type, so the signal arguments arrive
+	// as plain objects.
+	disp.Connect("monitor-added", func(_, _ *glib.Object) {
+		bs.sync()
+		// The output name of a new monitor may only be known once the
+		// compositor has announced it on our own connection.
+		glib.TimeoutAdd(uint(1000), func() bool {
+			bs.sync()
+			return false
+		})
+	})
+	disp.Connect("monitor-removed", func(_, _ *glib.Object) {
+		bs.sync()
+	})
+
+	// Editors often write a file in several steps, so the events that
+	// arrive before the main loop gets to the reload are coalesced.
+	var pending atomic.Bool
+	_, err = config.WatchBar(func() {
+		if pending.Swap(true) {
+			return
+		}
+		glib.IdleAdd(func() {
+			pending.Store(false)
+			bs.reload()
+		})
+	})
+	if err != nil {
+		log.Println("Error watching bar configuration:", err)
+	}
+}
+
+// reload reads the bar configuration again and rebuilds the bars if it
+// changed. A configuration that fails to load, as while it is being edited,
+// leaves the bars as they are.
+func (bs *barSet) reload() {
+	cfg, err := config.LoadBar()
+	if err != nil {
+		log.Println("Error loading bar configuration:", err)
+		return
+	}
+	if reflect.DeepEqual(cfg, bs.cfg) {
+		return
+	}
+
+	bs.cfg = cfg
+	for key, win := range bs.bars {
+		win.Destroy()
+		delete(bs.bars, key)
+	}
+	bs.sync()
+}
+
+// sync creates the missing bars and destroys those whose monitor is gone or
+// no longer selected.
+func (bs *barSet) sync() {
+	wanted := make(map[uintptr]*gdk.Monitor)
+	for _, mon := range bs.selectedMonitors() {
+		wanted[mon.Native()] = mon
+	}
+
+	for key, win := range bs.bars {
+		if _, ok := wanted[key]; !ok {
+			win.Destroy()
+			delete(bs.bars, key)
+		}
+	}
+
+	for key, mon := range wanted {
+		if _, ok := bs.bars[key]; !ok {
+			win := createBar(bs.nDaemon, mon, bs.cfg)
+			win.ShowAll()
+			bs.bars[key] = win
+		}
+	}
+}
+
+// selectedMonitors returns the monitors that get a bar.
+func (bs *barSet) selectedMonitors() []*gdk.Monitor {
+	var monitors []*gdk.Monitor
+	for i := 0; i < bs.disp.GetNMonitors(); i++ {
+		if mon, err := bs.disp.GetMonitor(i); err == nil {
+			monitors = append(monitors, mon)
+		}
+	}
+	if len(monitors) == 0 {
+		return nil
+	}
+
+	switch bs.cfg.Outputs.Mode {
+	case config.OutputsAll:
+		return monitors
+	case config.OutputsPrimary:
+		if mon, err := bs.disp.GetPrimaryMonitor(); err == nil && mon != nil {
+			return []*gdk.Monitor{mon}
+		}
+		return monitors[:1]
+	}
+
+	var selected []*gdk.Monitor
+	for _, mon := range monitors {
+		if slices.Contains(bs.cfg.Outputs.Names, monitorName(mon)) {
+			selected = append(selected, mon)
+		}
+	}
+	return selected
+}
+
+// monitorName returns the output name of mon, such as "DP-1". GTK 3 does not
+// expose connector names, so the monitor is matched with the output at the
+// same logical position as reported by the compositor.
+func monitorName(mon *gdk.Monitor) string {
+	if o, ok := monitorOutput(mon); ok && o.Name != "" {
+		return o.Name
+	}
+	return mon.GetModel()
+}
+
+func monitorOutput(mon *gdk.Monitor) (toplevel.Output, bool) {
+	geometry := mon.GetGeometry()
+	for _, o := range getToplevels().outputs() {
+		if o.X == geometry.GetX() && o.Y == geometry.GetY() {
+			return o, true
+		}
+	}
+	return toplevel.Output{}, false
+}
+
+// onMonitor reports whether the window t is shown on mon.
+func onMonitor(t toplevel.Toplevel, mon *gdk.Monitor) bool {
+	o, ok := monitorOutput(mon)
+	return ok && t.OnOutput(o)
+}
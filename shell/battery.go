@@ -0,0 +1,312 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/AuruTeam/desktop/power"
+	"github.com/AuruTeam/desktoplib/batteryHandler"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// profileLabels names the power profiles in the popover.
+var profileLabels = map[string]string{
+	power.ProfilePowerSaver:  "Power saver",
+	power.ProfileBalanced:    "Balanced",
+	power.ProfilePerformance: "Performance",
+}
+
+// batteryControl is the battery icon of the status module. Its tooltip shows
+// the charge, and clicking it opens a popover with the batteries of the
+// computer and its peripherals and the power profile.
+type batteryControl struct {
+	client *power.Client
+
+	button  *gtk.Button
+	image   *gtk.Image
+	popover *gtk.Popover
+
+	percentage *gtk.Label
+	summary    *gtk.Label
+	devices    *gtk.Box
+	profiles   *gtk.Box
+	degraded   *gtk.Label
+
+	state batteryState
+
+	// reading is set while the state is read in the background, and stale
+	// if it changed again meanwhile.
+	reading, stale bool
+	destroyed      bool
+}
+
+// batteryState is what the battery icon and its popover show.
+type batteryState struct {
+	icon        string
+	display     power.Device
+	displayErr  error
+	devices     []power.Device
+	profiles    power.Profiles
+	profilesErr error
+}
+
+// readBatteryState reads the batteries and power profiles. It makes
+// synchronous D-Bus calls and is run in the background.
+func readBatteryState(client *power.Client) batteryState {
+	var state batteryState
+	state.icon = batteryHandler.GetBatteryIcon()
+	state.display, state.displayErr = client.Display()
+
+	devices, err := client.Devices()
+	if err != nil {
+		log.Println("Error reading the batteries:", err)
+	}
+	state.devices = devices
+
+	state.profiles, state.profilesErr = client.Profiles()
+	if state.profilesErr != nil && !errors.Is(state.profilesErr, power.ErrNoProfiles) {
+		log.Println("Error reading the power profiles:", state.profilesErr)
+	}
+	return state
+}
+
+// newBatteryControl creates the battery icon with its popover.
+func newBatteryControl(ctx ModuleContext, client *power.Client) *batteryControl {
+	b := &batteryControl{client: client}
+
+	b.button, _ = gtk.ButtonNew()
+	b.button.SetRelief(gtk.RELIEF_NONE)
+	b.image, _ = gtk.ImageNew()
+	sc, _ := b.image.GetStyleContext()
+	sc.AddClass("power")
+	b.button.Add(b.image)
+
+	b.popover, _ = gtk.PopoverNew(b.button)
+	b.popover.SetPosition(ctx.PopoverPosition())
+	sc, _ = b.popover.GetStyleContext()
+	sc.AddClass("battery-menu")
+	b.popover.Add(b.createMenu())
+
+	b.button.Connect("clicked", func() {
+		if b.popover.IsVisible() {
+			b.popover.Popdown()
+			return
+		}
+		// The popover opens with the last state read; update refreshes it
+		// once the current one is read.
+		b.fill()
+		b.popover.ShowAll()
+		b.popover.Popup()
+		b.update()
+	})
+	b.button.Connect("destroy", func() {
+		b.destroyed = true
+		b.popover.Destroy()
+		b.client.Close()
+	})
+	return b
+}
+
+func (b *batteryControl) createMenu() *gtk.Box {
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+
+	b.percentage, _ = gtk.LabelNew("")
+	b.percentage.SetXAlign(0)
+	sc, _ := b.percentage.GetStyleContext()
+	sc.AddClass("h2")
+	b.summary, _ = gtk.LabelNew("")
+	b.summary.SetXAlign(0)
+	box.PackStart(b.percentage, false, false, 0)
+	box.PackStart(b.summary, false, false, 0)
+
+	b.devices, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+	sc, _ = b.devices.GetStyleContext()
+	sc.AddClass("battery-devices")
+	box.PackStart(b.devices, false, false, 0)
+
+	b.profiles, _ = gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 0)
+	sc, _ = b.profiles.GetStyleContext()
+	sc.AddClass("linked")
+	sc.AddClass("power-profiles")
+	box.PackStart(b.profiles, false, false, 0)
+
+	b.degraded, _ = gtk.LabelNew("")
+	b.degraded.SetXAlign(0)
+	b.degraded.SetLineWrap(true)
+	b.degraded.SetNoShowAll(true)
+	sc, _ = b.degraded.GetStyleContext()
+	sc.AddClass("dim-label")
+	box.PackStart(b.degraded, false, false, 0)
+	return box
+}
+
+// update refreshes the icon and tooltip, and the popover if it is open.
+// The state is read in the background; changes that come in meanwhile are
+// read once it is done.
+func (b *batteryControl) update() {
+	if !batteryHandler.IsBattery() {
+		return
+	}
+	if b.reading {
+		b.stale = true
+		return
+	}
+	b.reading = true
+
+	go func() {
+		state := readBatteryState(b.client)
+		glib.IdleAdd(func() {
+			b.reading = false
+			if b.destroyed {
+				return
+			}
+			b.show(state)
+			if b.stale {
+				b.stale = false
+				b.update()
+			}
+		})
+	}()
+}
+
+// show makes the icon, and the popover if it is open, show state.
+func (b *batteryControl) show(state batteryState) {
+	b.state = state
+	b.image.SetFromIconName(state.icon, gtk.ICON_SIZE_BUTTON)
+	if state.displayErr != nil {
+		log.Println("Error reading the battery:", state.displayErr)
+	} else {
+		b.button.SetTooltipText(fmt.Sprintf("%d%% — %s", int(math.Round(state.display.Percentage)), batterySummary(state.display)))
+	}
+
+	if b.popover.IsVisible() {
+		b.fill()
+		b.popover.ShowAll()
+	}
+}
+
+// fill shows the batteries and power profiles of the current state in the
+// popover.
+func (b *batteryControl) fill() {
+	state := b.state
+	if state.displayErr == nil {
+		b.percentage.SetText(fmt.Sprintf("%d%%", int(math.Round(state.display.Percentage))))
+		b.summary.SetText(batterySummary(state.display))
+	}
+
+	clearContainer(&b.devices.Container)
+	// With a single battery in the computer, the header says it all.
+	internal := 0
+	for _, d := range state.devices {
+		if d.PowerSupply {
+			internal++
+		}
+	}
+	for _, d := range state.devices {
+		if d.PowerSupply && internal < 2 {
+			continue
+		}
+		b.devices.PackStart(batteryRow(d), false, false, 0)
+	}
+
+	clearContainer(&b.profiles.Container)
+	profiles := state.profiles
+	if state.profilesErr != nil {
+		b.degraded.Hide()
+		return
+	}
+	var group *gtk.RadioButton
+	for _, name := range profiles.Available {
+		label, ok := profileLabels[name]
+		if !ok {
+			label = name
+		}
+		button, _ := gtk.RadioButtonNewWithLabelFromWidget(group, label)
+		group = button
+		button.SetMode(false)
+		button.SetActive(name == profiles.Active)
+		button.Connect("toggled", func() {
+			if button.GetActive() && name != profiles.Active {
+				b.setProfile(name)
+			}
+		})
+		b.profiles.PackStart(button, true, true, 0)
+	}
+
+	if profiles.Degraded != "" {
+		reason := strings.ReplaceAll(profiles.Degraded, "-", " ")
+		b.degraded.SetText("Performance is limited: " + reason)
+		b.degraded.Show()
+	} else {
+		b.degraded.Hide()
+	}
+}
+
+// setProfile switches the power profile in the background. The watch of the
+// client reports the change; a failed switch is undone in the popover.
+func (b *batteryControl) setProfile(name string) {
+	go func() {
+		err := b.client.SetProfile(name)
+		logPowerError(err)
+		if err != nil {
+			glib.IdleAdd(b.update)
+		}
+	}()
+}
+
+// batteryRow shows the charge of one battery.
+func batteryRow(d power.Device) *gtk.Box {
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	sc, _ := row.GetStyleContext()
+	sc.AddClass("battery-device")
+
+	icon := d.IconName
+	if icon == "" {
+		icon = "battery-symbolic"
+	}
+	image, _ := gtk.ImageNewFromIconName(icon, gtk.ICON_SIZE_BUTTON)
+	name := ellipsizedLabel(d.Name())
+	level, _ := gtk.LevelBarNewForInterval(0, 100)
+	level.SetValue(d.Percentage)
+	level.SetSizeRequest(80, -1)
+	level.SetVAlign(gtk.ALIGN_CENTER)
+	percentage, _ := gtk.LabelNew(fmt.Sprintf("%d%%", int(math.Round(d.Percentage))))
+
+	row.PackStart(image, false, false, 0)
+	row.PackStart(name, true, true, 0)
+	row.PackStart(level, false, false, 0)
+	row.PackStart(percentage, false, false, 0)
+	row.SetTooltipText(fmt.Sprintf("%s, %s", d.Kind, batterySummary(d)))
+	return row
+}
+
+// batterySummary describes the state of a battery and how long it lasts.
+func batterySummary(d power.Device) string {
+	switch {
+	case d.State == power.StateDischarging && d.TimeToEmpty > 0:
+		return fmt.Sprintf("%s until empty", formatDuration(d.TimeToEmpty))
+	case d.State == power.StateCharging && d.TimeToFull > 0:
+		return fmt.Sprintf("Charging, %s until full", formatDuration(d.TimeToFull))
+	}
+	return d.State.String()
+}
+
+// formatDuration formats a duration in hours and minutes, like "2 h 05 min".
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	return fmt.Sprintf("%d h %02d min", minutes/60, minutes%60)
+}
+
+func logPowerError(err error) {
+	if err != nil {
+		log.Println("Failed to switch the power profile:", err)
+	}
+}
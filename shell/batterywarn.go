@@ -0,0 +1,331 @@
+package shell
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/notification"
+	"github.com/AuruTeam/desktop/power"
+	"github.com/dlasky/gotk3-layershell/layershell"
+	"github.com/godbus/dbus/v5"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// batteryMonitor warns when the battery runs low and takes the configured
+// action before it runs out.
+type batteryMonitor struct {
+	cfg    config.Power
+	client *power.Client
+
+	// warned and critical are set once the low warning was shown and the
+	// critical action was started during the current discharge.
+	warned   bool
+	critical bool
+
+	countdown *gtk.Window
+
+	// reading is set while the battery is read in the background, and
+	// stale if it changed again meanwhile.
+	reading, stale bool
+
+	// queue holds the notifications to send and withdraw. A single
+	// goroutine works through it while sending is set, so that a
+	// withdrawal comes after the notification it withdraws.
+	mu      sync.Mutex
+	queue   []batteryNotification
+	sending bool
+	// notification is the ID of the last notification, which the next one
+	// replaces. It is only accessed by the sending goroutine.
+	notification uint32
+}
+
+// batteryNotification is a notification of the battery monitor, or the
+// withdrawal of the last one if withdraw is set.
+type batteryNotification struct {
+	summary, body, icon string
+	urgency             notification.Urgency
+	withdraw            bool
+}
+
+// WatchBattery shows a notification when the battery runs low and suspends,
+// hibernates or powers off the computer when it is critically low, as
+// configured in power.json. The user can cancel the critical action during
+// a countdown.
+func WatchBattery() {
+	cfg, err := config.LoadPower()
+	if err != nil {
+		log.Println("Error loading power configuration:", err)
+	}
+
+	client, err := power.Connect()
+	if err != nil {
+		log.Println("Battery warnings disabled:", err)
+		return
+	}
+
+	m := &batteryMonitor{cfg: cfg, client: client}
+	watchStatus("battery warnings", client, m.update)
+}
+
+// update reads the battery in the background and then checks it on the
+// main loop. Changes that come in meanwhile are read once it is done.
+func (m *batteryMonitor) update() {
+	if m.reading {
+		m.stale = true
+		return
+	}
+	m.reading = true
+
+	go func() {
+		battery, err := m.client.Display()
+		glib.IdleAdd(func() {
+			m.reading = false
+			if err == nil {
+				m.check(battery)
+			}
+			if m.stale {
+				m.stale = false
+				m.update()
+			}
+		})
+	}()
+}
+
+// check warns about the battery or takes the critical action as its level
+// calls for.
+func (m *batteryMonitor) check(battery power.Device) {
+	if battery.Kind != power.KindBattery {
+		return
+	}
+
+	if battery.State != power.StateDischarging {
+		if m.warned || m.critical {
+			m.warned, m.critical = false, false
+			m.cancelCountdown()
+			m.closeNotification()
+		}
+		return
+	}
+
+	level := int(math.Round(battery.Percentage))
+	if level > m.cfg.LowLevel {
+		m.warned = false
+	}
+	switch {
+	case level <= m.cfg.CriticalLevel && !m.critical:
+		m.warned, m.critical = true, true
+		body := fmt.Sprintf("%d%% remaining.", level)
+		if verb := actionVerb(m.cfg.CriticalAction); verb != "" {
+			body += fmt.Sprintf(" The computer will %s soon.", verb)
+		}
+		m.notify("Battery critically low", body, "battery-empty-symbolic", notification.UrgencyCritical)
+		m.startCountdown()
+	case level <= m.cfg.LowLevel && !m.warned:
+		m.warned = true
+		body := fmt.Sprintf("%d%% remaining.", level)
+		if battery.TimeToEmpty > 0 {
+			body = fmt.Sprintf("%d%% remaining, about %s.", level, formatDuration(battery.TimeToEmpty))
+		}
+		m.notify("Battery low", body, "battery-caution-symbolic", notification.UrgencyNormal)
+	}
+}
+
+// actionVerb describes a critical action for the user.
+func actionVerb(action string) string {
+	switch action {
+	case config.CriticalActionSuspend:
+		return "suspend"
+	case config.CriticalActionHibernate:
+		return "hibernate"
+	case config.CriticalActionPowerOff:
+		return "shut down"
+	}
+	return ""
+}
+
+// startCountdown shows a window counting down to the critical action, which
+// the user can cancel or take right away.
+func (m *batteryMonitor) startCountdown() {
+	verb := actionVerb(m.cfg.CriticalAction)
+	if verb == "" {
+		return
+	}
+	if m.cfg.ActionDelay == 0 {
+		m.runCriticalAction()
+		return
+	}
+
+	win, _ := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	win.SetTitle("Battery critically low")
+	layershell.InitForWindow(win)
+	layershell.SetNamespace(win, "miracleos")
+	layershell.SetLayer(win, layershell.LAYER_SHELL_LAYER_OVERLAY)
+	layershell.SetKeyboardMode(win, layershell.LAYER_SHELL_KEYBOARD_MODE_ON_DEMAND)
+
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 15)
+	sc, _ := box.GetStyleContext()
+	sc.AddClass("battery-countdown")
+
+	title, _ := gtk.LabelNew("Battery critically low")
+	sc, _ = title.GetStyleContext()
+	sc.AddClass("h1")
+	message, _ := gtk.LabelNew("")
+
+	buttons, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
+	buttons.SetHAlign(gtk.ALIGN_CENTER)
+	cancel, _ := gtk.ButtonNewWithLabel("Cancel")
+	cancel.Connect("clicked", m.cancelCountdown)
+	now, _ := gtk.ButtonNewWithLabel(fmt.Sprintf("%s now", firstUpper(verb)))
+	now.Connect("clicked", func() {
+		m.cancelCountdown()
+		m.runCriticalAction()
+	})
+	buttons.PackStart(cancel, false, false, 0)
+	buttons.PackStart(now, false, false, 0)
+
+	box.PackStart(title, false, false, 0)
+	box.PackStart(message, false, false, 0)
+	box.PackStart(buttons, false, false, 0)
+	win.Add(box)
+	win.Connect("key-press-event", func(_ *gtk.Window, ev *gdk.Event) bool {
+		if gdk.EventKeyNewFromEvent(ev).KeyVal() == gdk.KEY_Escape {
+			m.cancelCountdown()
+			return true
+		}
+		return false
+	})
+
+	remaining := m.cfg.ActionDelay
+	showRemaining := func() {
+		message.SetText(fmt.Sprintf("Connect the charger. The computer will %s in %d seconds.", verb, remaining))
+	}
+	showRemaining()
+	handle := glib.TimeoutAdd(1000, func() bool {
+		remaining--
+		if remaining > 0 {
+			showRemaining()
+			return true
+		}
+		m.countdown = nil
+		win.Destroy()
+		m.runCriticalAction()
+		return false
+	})
+	win.Connect("destroy", func() {
+		if m.countdown == win {
+			m.countdown = nil
+		}
+		if remaining > 0 {
+			glib.SourceRemove(handle)
+		}
+	})
+
+	m.countdown = win
+	win.ShowAll()
+	cancel.GrabFocus()
+}
+
+// cancelCountdown closes the countdown window, if it is open, without taking
+// the action.
+func (m *batteryMonitor) cancelCountdown() {
+	if m.countdown != nil {
+		m.countdown.Destroy()
+		m.countdown = nil
+	}
+}
+
+func (m *batteryMonitor) runCriticalAction() {
+	var err error
+	switch m.cfg.CriticalAction {
+	case config.CriticalActionSuspend:
+		err = m.client.Suspend()
+	case config.CriticalActionHibernate:
+		err = m.client.Hibernate()
+	case config.CriticalActionPowerOff:
+		err = m.client.PowerOff()
+	}
+	if err != nil {
+		log.Printf("Failed to %s on critical battery: %v", actionVerb(m.cfg.CriticalAction), err)
+	}
+}
+
+// notify sends a desktop notification through the notification daemon,
+// replacing the previous one of the monitor.
+func (m *batteryMonitor) notify(summary, body, icon string, urgency notification.Urgency) {
+	m.enqueue(batteryNotification{summary: summary, body: body, icon: icon, urgency: urgency})
+}
+
+// closeNotification withdraws the last notification of the monitor.
+func (m *batteryMonitor) closeNotification() {
+	m.enqueue(batteryNotification{withdraw: true})
+}
+
+// enqueue queues n and starts the sending goroutine if it is not running.
+// The daemon may run in this very process, so the main loop must not wait
+// for it.
+func (m *batteryMonitor) enqueue(n batteryNotification) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queue = append(m.queue, n)
+	if !m.sending {
+		m.sending = true
+		go m.send()
+	}
+}
+
+// send sends the queued notifications in order until the queue is empty.
+func (m *batteryMonitor) send() {
+	for {
+		m.mu.Lock()
+		if len(m.queue) == 0 {
+			m.sending = false
+			m.mu.Unlock()
+			return
+		}
+		n := m.queue[0]
+		m.queue = m.queue[1:]
+		m.mu.Unlock()
+
+		conn, err := dbus.SessionBus()
+		if err != nil {
+			log.Println("Failed to send battery notification:", err)
+			continue
+		}
+		obj := conn.Object("org.freedesktop.Notifications", "/org/freedesktop/Notifications")
+
+		if n.withdraw {
+			if m.notification != 0 {
+				obj.Call("org.freedesktop.Notifications.CloseNotification", 0, m.notification)
+				m.notification = 0
+			}
+			continue
+		}
+
+		hints := map[string]dbus.Variant{"urgency": dbus.MakeVariant(byte(n.urgency))}
+		var id uint32
+		err = obj.Call(
+			"org.freedesktop.Notifications.Notify", 0,
+			"Battery", m.notification, n.icon, n.summary, n.body, []string{}, hints, int32(-1),
+		).Store(&id)
+		if err != nil {
+			log.Println("Failed to send battery notification:", err)
+			continue
+		}
+		m.notification = id
+	}
+}
+
+// firstUpper returns s with its first letter in upper case.
+func firstUpper(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,330 @@
+package shell
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AuruTeam/desktop/calendar"
+	"github.com/AuruTeam/desktop/clock"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// upcomingDays is how many days of events the calendar popover lists,
+// starting at the selected day.
+const upcomingDays = 7
+
+// calendarPopover shows a month calendar and the upcoming events of the
+// configured calendars.
+type calendarPopover struct {
+	paths  []string
+	cal    *calendar.Calendar
+	locale clock.Locale
+	// location is the time zone of the clock, which decides what today is.
+	location *time.Location
+	// timeLayout formats the start of events.
+	timeLayout string
+
+	popover *gtk.Popover
+	title   *gtk.Button
+	grid    *gtk.Grid
+	events  *gtk.Box
+
+	// month is the first day of the month shown, selected the day whose
+	// events are listed.
+	month    time.Time
+	selected time.Time
+
+	// loading is set while the calendars are read in the background.
+	loading   bool
+	destroyed bool
+}
+
+// newCalendarPopover creates the calendar popover of a clock module. paths
+// are .ics files or directories of them, and days and times are shown in
+// location.
+func newCalendarPopover(ctx ModuleContext, relative gtk.IWidget, paths []string, locale clock.Locale, location *time.Location, hour12 bool) *calendarPopover {
+	c := &calendarPopover{paths: paths, locale: locale, location: location, timeLayout: hoursMinutes(hour12)}
+
+	c.popover, _ = gtk.PopoverNew(relative)
+	c.popover.SetPosition(ctx.PopoverPosition())
+	sc, _ := c.popover.GetStyleContext()
+	sc.AddClass("calendar-menu")
+
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+
+	header, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	previous, _ := gtk.ButtonNewFromIconName("go-previous-symbolic", gtk.ICON_SIZE_BUTTON)
+	previous.SetRelief(gtk.RELIEF_NONE)
+	previous.SetTooltipText("Previous month")
+	previous.Connect("clicked", func() { c.showMonth(c.month.AddDate(0, -1, 0)) })
+	next, _ := gtk.ButtonNewFromIconName("go-next-symbolic", gtk.ICON_SIZE_BUTTON)
+	next.SetRelief(gtk.RELIEF_NONE)
+	next.SetTooltipText("Next month")
+	next.Connect("clicked", func() { c.showMonth(c.month.AddDate(0, 1, 0)) })
+	c.title, _ = gtk.ButtonNew()
+	c.title.SetRelief(gtk.RELIEF_NONE)
+	c.title.SetTooltipText(locale.Today)
+	c.title.Connect("clicked", c.showToday)
+	header.PackStart(previous, false, false, 0)
+	header.PackStart(c.title, true, true, 0)
+	header.PackStart(next, false, false, 0)
+
+	c.grid, _ = gtk.GridNew()
+	c.grid.SetColumnHomogeneous(true)
+	sc, _ = c.grid.GetStyleContext()
+	sc.AddClass("calendar-grid")
+
+	c.events, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+	sc, _ = c.events.GetStyleContext()
+	sc.AddClass("calendar-events")
+	scroll, _ := gtk.ScrolledWindowNew(nil, nil)
+	scroll.SetPolicy(gtk.POLICY_NEVER, gtk.POLICY_AUTOMATIC)
+	scroll.SetPropagateNaturalHeight(true)
+	scroll.SetMaxContentHeight(250)
+	scroll.Add(c.events)
+
+	box.PackStart(header, false, false, 0)
+	box.PackStart(c.grid, false, false, 0)
+	box.PackStart(scroll, true, true, 0)
+	c.popover.Add(box)
+	c.popover.Connect("destroy", func() { c.destroyed = true })
+	return c
+}
+
+// toggle opens the popover on today, reading the calendars again, or
+// closes it.
+func (c *calendarPopover) toggle() {
+	if c.popover.IsVisible() {
+		c.popover.Popdown()
+		return
+	}
+	c.showToday()
+	c.popover.ShowAll()
+	c.popover.Popup()
+	c.load()
+}
+
+// load reads the calendars in the background and then shows their events.
+// Until then, the popover shows the events read the last time.
+func (c *calendarPopover) load() {
+	if c.loading {
+		return
+	}
+	c.loading = true
+
+	paths := c.paths
+	go func() {
+		cal, err := calendar.Load(paths...)
+		if err != nil {
+			log.Println("Error reading calendars:", err)
+		}
+		glib.IdleAdd(func() {
+			c.loading = false
+			if c.destroyed {
+				return
+			}
+			c.cal = cal
+			c.fillGrid()
+			c.fillEvents()
+		})
+	}()
+}
+
+func (c *calendarPopover) showToday() {
+	c.selected = c.startOfDay(time.Now())
+	c.showMonth(c.selected)
+}
+
+// showMonth shows the month of t.
+func (c *calendarPopover) showMonth(t time.Time) {
+	c.month = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, c.location)
+	c.title.SetLabel(c.locale.Format(c.month, "%OB %Y"))
+	c.fillGrid()
+	c.fillEvents()
+}
+
+// fillGrid shows the weeks of the month, starting on Monday, with their ISO
+// week numbers.
+func (c *calendarPopover) fillGrid() {
+	clearContainer(&c.grid.Container)
+
+	// Six weeks fit every month and keep the popover from changing size.
+	first := c.month.AddDate(0, 0, -(int(c.month.Weekday())+6)%7)
+	last := first.AddDate(0, 0, 6*7)
+	busy := c.busyDays(first, last)
+	today := c.startOfDay(time.Now())
+
+	for i := 0; i < 7; i++ {
+		name := c.locale.ShortDays[(i+1)%7]
+		label, _ := gtk.LabelNew(firstN(name, 2))
+		sc, _ := label.GetStyleContext()
+		sc.AddClass("calendar-weekday")
+		c.grid.Attach(label, i+1, 0, 1, 1)
+	}
+
+	for week := 0; week < 6; week++ {
+		monday := first.AddDate(0, 0, 7*week)
+		_, number := monday.ISOWeek()
+		label, _ := gtk.LabelNew(strconv.Itoa(number))
+		sc, _ := label.GetStyleContext()
+		sc.AddClass("calendar-week")
+		sc.AddClass("dim-label")
+		c.grid.Attach(label, 0, week+1, 1, 1)
+
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			button, _ := gtk.ButtonNewWithLabel(strconv.Itoa(day.Day()))
+			button.SetRelief(gtk.RELIEF_NONE)
+			sc, _ := button.GetStyleContext()
+			sc.AddClass("calendar-day")
+			if day.Equal(today) {
+				sc.AddClass("today")
+			}
+			if day.Equal(c.selected) {
+				sc.AddClass("selected")
+			}
+			if day.Month() != c.month.Month() {
+				sc.AddClass("other-month")
+			}
+			if busy[day] {
+				sc.AddClass("has-events")
+			}
+			button.Connect("clicked", func() {
+				c.selected = day
+				c.showMonth(day)
+			})
+			c.grid.Attach(button, i+1, week+1, 1, 1)
+		}
+	}
+	c.grid.ShowAll()
+}
+
+// busyDays returns the days from first to last that have events.
+func (c *calendarPopover) busyDays(first, last time.Time) map[time.Time]bool {
+	busy := make(map[time.Time]bool)
+	if c.cal == nil {
+		return busy
+	}
+	for _, e := range c.cal.Between(first, last) {
+		for day := c.startOfDay(e.Start); day.Before(last); day = day.AddDate(0, 0, 1) {
+			if !day.Before(e.End) && !day.Equal(c.startOfDay(e.Start)) {
+				break
+			}
+			busy[day] = true
+		}
+	}
+	return busy
+}
+
+// fillEvents lists the events of the days following the selected one.
+func (c *calendarPopover) fillEvents() {
+	clearContainer(&c.events.Container)
+
+	var events []calendar.Event
+	if c.cal != nil {
+		events = c.cal.Between(c.selected, c.selected.AddDate(0, 0, upcomingDays))
+	}
+	if len(events) == 0 {
+		label, _ := gtk.LabelNew(c.locale.NoEvents)
+		sc, _ := label.GetStyleContext()
+		sc.AddClass("dim-label")
+		c.events.PackStart(label, false, false, 0)
+		c.events.ShowAll()
+		return
+	}
+
+	var heading time.Time
+	for _, e := range events {
+		// Events that started before the selected day are listed under it.
+		day := c.startOfDay(e.Start)
+		if day.Before(c.selected) {
+			day = c.selected
+		}
+		if !day.Equal(heading) {
+			heading = day
+			label, _ := gtk.LabelNew(c.dayHeading(day))
+			label.SetXAlign(0)
+			sc, _ := label.GetStyleContext()
+			sc.AddClass("calendar-event-day")
+			c.events.PackStart(label, false, false, 0)
+		}
+		c.events.PackStart(c.eventRow(e), false, false, 0)
+	}
+	c.events.ShowAll()
+}
+
+// eventRow shows the time and summary of an event.
+func (c *calendarPopover) eventRow(e calendar.Event) *gtk.Box {
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
+	sc, _ := row.GetStyleContext()
+	sc.AddClass("calendar-event")
+
+	when := c.locale.AllDay
+	if !e.AllDay {
+		when = c.locale.Format(e.Start.In(c.location), c.timeLayout)
+	}
+	timeLabel, _ := gtk.LabelNew(when)
+	timeLabel.SetXAlign(0)
+	timeLabel.SetWidthChars(8)
+	sc, _ = timeLabel.GetStyleContext()
+	sc.AddClass("dim-label")
+
+	summary := e.Summary
+	if summary == "" {
+		summary = "(No title)"
+	}
+	row.PackStart(timeLabel, false, false, 0)
+	row.PackStart(ellipsizedLabel(summary), true, true, 0)
+
+	var details []string
+	if !e.AllDay {
+		start := c.locale.Format(e.Start.In(c.location), c.timeLayout)
+		end := c.locale.Format(e.End.In(c.location), c.timeLayout)
+		details = append(details, fmt.Sprintf("%s – %s", start, end))
+	}
+	if e.Location != "" {
+		details = append(details, e.Location)
+	}
+	if e.Description != "" {
+		details = append(details, e.Description)
+	}
+	row.SetTooltipText(strings.Join(append([]string{summary}, details...), "\n"))
+	return row
+}
+
+// dayHeading names a day in the event list.
+func (c *calendarPopover) dayHeading(day time.Time) string {
+	today := c.startOfDay(time.Now())
+	switch {
+	case day.Equal(today):
+		return c.locale.Today
+	case day.Equal(today.AddDate(0, 0, 1)):
+		return c.locale.Tomorrow
+	}
+	return c.locale.Format(day, "%A, %-d %B")
+}
+
+// startOfDay returns midnight of the day of t in the time zone of the
+// clock.
+func (c *calendarPopover) startOfDay(t time.Time) time.Time {
+	year, month, day := t.In(c.location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, c.location)
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
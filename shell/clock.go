@@ -0,0 +1,158 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AuruTeam/desktop/calendar"
+	"github.com/AuruTeam/desktop/clock"
+	"github.com/AuruTeam/desktop/config"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// clockOptions configures a clock module.
+type clockOptions struct {
+	// Time and Date are strftime-like layouts of the two lines of the
+	// clock. Without Time, Hour12 and Seconds choose one.
+	Time string `json:"time"`
+	Date string `json:"date"`
+	// Hour12 chooses the 12-hour clock, also for the times of events; the
+	// one of the locale by default.
+	Hour12  *bool `json:"hour12"`
+	Seconds bool  `json:"seconds"`
+	// Timezone is an IANA time zone name such as Europe/Berlin; the local
+	// time zone by default.
+	Timezone string `json:"timezone"`
+	// Locale names the language of day and month names, such as ru_RU; the
+	// one of the environment by default.
+	Locale string `json:"locale"`
+	// WorldClocks are shown in the tooltip.
+	WorldClocks []worldClock `json:"worldClocks"`
+	// Calendars are the .ics files, or directories of them, whose events
+	// the calendar popover lists.
+	Calendars []string `json:"calendars"`
+}
+
+type worldClock struct {
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+
+	location *time.Location
+}
+
+// hour12 tells whether the clock uses the 12-hour clock.
+func (o clockOptions) hour12(locale clock.Locale) bool {
+	if o.Hour12 != nil {
+		return *o.Hour12
+	}
+	return locale.Hour12
+}
+
+// timeLayout returns the layout of the time line.
+func (o clockOptions) timeLayout(hour12 bool) string {
+	if o.Time != "" {
+		return o.Time
+	}
+	layout := "%H:%M"
+	if hour12 {
+		layout = "%-I:%M"
+	}
+	if o.Seconds {
+		layout += ":%S"
+	}
+	if hour12 {
+		layout += " %p"
+	}
+	return layout
+}
+
+// hoursMinutes returns the layout of a time of day in hours and minutes.
+func hoursMinutes(hour12 bool) string {
+	if hour12 {
+		return "%-I:%M %p"
+	}
+	return "%H:%M"
+}
+
+// newClockModule creates the clock. Clicking it opens a calendar with the
+// upcoming events.
+func newClockModule(ctx ModuleContext, entry config.Module) (*Module, error) {
+	opts := clockOptions{Date: "%a %-d %b", Calendars: []string{calendar.DefaultDir()}}
+	if err := entry.Decode(&opts); err != nil {
+		return nil, err
+	}
+	for i, path := range opts.Calendars {
+		opts.Calendars[i] = expandHome(path)
+	}
+
+	location := time.Local
+	if opts.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(opts.Timezone); err != nil {
+			return nil, fmt.Errorf("timezone: %w", err)
+		}
+	}
+	for i, w := range opts.WorldClocks {
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("world clock %q: %w", w.Name, err)
+		}
+		opts.WorldClocks[i].location = loc
+		if w.Name == "" {
+			// Europe/Berlin becomes Berlin.
+			opts.WorldClocks[i].Name = strings.ReplaceAll(w.Timezone[strings.LastIndex(w.Timezone, "/")+1:], "_", " ")
+		}
+	}
+	locale := clock.LookupLocale(opts.Locale)
+	hour12 := opts.hour12(locale)
+	timeLayout := opts.timeLayout(hour12)
+
+	button, _ := gtk.ButtonNew()
+	button.SetRelief(gtk.RELIEF_NONE)
+	sc, _ := button.GetStyleContext()
+	sc.AddClass("clock")
+	box, _ := gtk.BoxNew(ctx.Orientation(), 0)
+	button.Add(box)
+
+	timeText, _ := gtk.LabelNew("")
+	sc, _ = timeText.GetStyleContext()
+	sc.AddClass("clock-text")
+
+	dayText, _ := gtk.LabelNew("")
+	dayText.SetNoShowAll(opts.Date == "")
+	sc, _ = dayText.GetStyleContext()
+	sc.AddClass("day-text")
+
+	box.PackStart(timeText, false, false, 0)
+	box.PackStart(dayText, false, false, 0)
+
+	popover := newCalendarPopover(ctx, button, opts.Calendars, locale, location, hour12)
+	button.Connect("clicked", popover.toggle)
+	button.Connect("destroy", popover.popover.Destroy)
+
+	// The labels are only set when the text changes, so that an open
+	// tooltip does not flicker.
+	var shown [3]string
+	setText := func(i int, text string, set func(string)) {
+		if shown[i] != text {
+			shown[i] = text
+			set(text)
+		}
+	}
+
+	return &Module{Widget: button, Sources: []ModuleSource{{
+		Interval: 500 * time.Millisecond,
+		Update: func() {
+			now := time.Now().In(location)
+			setText(0, locale.Format(now, timeLayout), timeText.SetText)
+			setText(1, locale.Format(now, opts.Date), dayText.SetText)
+
+			lines := []string{locale.Format(now, "%A, %-d %B %Y")}
+			for _, w := range opts.WorldClocks {
+				lines = append(lines, fmt.Sprintf("%s: %s", w.Name, locale.Format(now.In(w.location), "%a "+timeLayout)))
+			}
+			setText(2, strings.Join(lines, "\n"), button.SetTooltipText)
+		},
+	}}}, nil
+}
@@ -0,0 +1,59 @@
+package shell
+
+import (
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// isSecondaryClick reports whether ev is a right-click.
+func isSecondaryClick(ev *gdk.Event) bool {
+	return gdk.EventButtonNewFromEvent(ev).Button() == gdk.BUTTON_SECONDARY
+}
+
+// popupMenu shows a context menu with the given items at the pointer. The
+// menu destroys itself once it is closed.
+func popupMenu(ev *gdk.Event, items ...gtk.IMenuItem) *gtk.Menu {
+	menu := newContextMenu(items...)
+	menu.PopupAtPointer(ev)
+	return menu
+}
+
+// popupMenuAtWidget shows menu on the given side of widget, aligned with its
+// start.
+func popupMenuAtWidget(menu *gtk.Menu, widget gtk.IWidget, position gtk.PositionType) {
+	widgetAnchor, menuAnchor := gdk.GDK_GRAVITY_SOUTH_WEST, gdk.GDK_GRAVITY_NORTH_WEST
+	switch position {
+	case gtk.POS_TOP:
+		widgetAnchor, menuAnchor = gdk.GDK_GRAVITY_NORTH_WEST, gdk.GDK_GRAVITY_SOUTH_WEST
+	case gtk.POS_LEFT:
+		widgetAnchor, menuAnchor = gdk.GDK_GRAVITY_NORTH_WEST, gdk.GDK_GRAVITY_NORTH_EAST
+	case gtk.POS_RIGHT:
+		widgetAnchor, menuAnchor = gdk.GDK_GRAVITY_NORTH_EAST, gdk.GDK_GRAVITY_NORTH_WEST
+	}
+	menu.PopupAtWidget(widget, widgetAnchor, menuAnchor, nil)
+}
+
+// newContextMenu returns a context menu with the given items, ready to be
+// popped up. The menu destroys itself once it is closed.
+func newContextMenu(items ...gtk.IMenuItem) *gtk.Menu {
+	menu, _ := gtk.MenuNew()
+	for _, item := range items {
+		menu.Append(item)
+	}
+
+	// Items are activated after the menu is deactivated, so keep the menu
+	// around until the main loop is idle again.
+	menu.Connect("deactivate", func() {
+		glib.IdleAdd(menu.Destroy)
+	})
+
+	menu.ShowAll()
+	return menu
+}
+
+// menuSeparator returns a separator for context menus.
+func menuSeparator() *gtk.SeparatorMenuItem {
+	item, _ := gtk.SeparatorMenuItemNew()
+	return item
+}
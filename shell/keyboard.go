@@ -0,0 +1,228 @@
+package shell
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/keyboard"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// keyboardOptions are the options of the keyboard module in the bar layout.
+type keyboardOptions struct {
+	// Backend is "auto", "sway" or "hyprland".
+	Backend string `json:"backend"`
+	// Layouts are the layouts to switch between, in order. All layouts
+	// of the backend are used if it is empty.
+	Layouts []keyboardLayout `json:"layouts"`
+}
+
+type keyboardLayout struct {
+	// Name matches the name, such as "English (US)", or the code, such as
+	// "us", of a layout of the backend.
+	Name string `json:"name"`
+	// Label is shown on the bar while the layout is active.
+	Label string `json:"label"`
+}
+
+// keyboardIndicator shows the active keyboard layout and switches layouts.
+type keyboardIndicator struct {
+	opts    keyboardOptions
+	backend keyboard.Backend
+	state   keyboard.State
+	label   *gtk.Label
+
+	reading   bool
+	stale     bool
+	destroyed bool
+}
+
+// newKeyboardModule creates the keyboard layout indicator. Clicking it
+// switches to the next layout; right-clicking lists the layouts.
+func newKeyboardModule(ctx ModuleContext, entry config.Module) (*Module, error) {
+	opts := keyboardOptions{Backend: "auto"}
+	if err := entry.Decode(&opts); err != nil {
+		return nil, err
+	}
+
+	otherIcons, _ := gtk.BoxNew(ctx.Orientation(), 0)
+	sc, _ := otherIcons.GetStyleContext()
+	sc.AddClass("other-icons-wrapper")
+
+	backend, err := keyboard.Open(opts.Backend)
+	if err != nil {
+		// Without a backend there is nothing to show but the icon.
+		log.Println("Keyboard layout indicator disabled:", err)
+		keyboardImage, _ := gtk.ImageNewFromIconName("input-keyboard-symbolic", gtk.ICON_SIZE_BUTTON)
+		sc, _ = keyboardImage.GetStyleContext()
+		sc.AddClass("keyboard")
+		otherIcons.PackStart(keyboardImage, false, false, 0)
+		return &Module{Widget: otherIcons}, nil
+	}
+
+	k := &keyboardIndicator{opts: opts, backend: backend}
+	button, _ := gtk.ButtonNew()
+	sc, _ = button.GetStyleContext()
+	sc.AddClass("keyboard")
+	k.label, _ = gtk.LabelNew("")
+	button.Add(k.label)
+	button.Connect("clicked", k.next)
+	button.Connect("button-press-event", func(_ *gtk.Button, ev *gdk.Event) bool {
+		if !isSecondaryClick(ev) {
+			return false
+		}
+		popupMenu(ev, k.menuItems()...)
+		return true
+	})
+	otherIcons.PackStart(button, false, false, 0)
+	otherIcons.Connect("destroy", func() {
+		k.destroyed = true
+	})
+
+	return &Module{Widget: otherIcons, Sources: []ModuleSource{{Status: backend, Update: k.update}}}, nil
+}
+
+// update reads the layouts from the backend in the background and shows
+// them. Changes that come in meanwhile are read once it is done.
+func (k *keyboardIndicator) update() {
+	if k.reading {
+		k.stale = true
+		return
+	}
+	k.reading = true
+
+	go func() {
+		state, err := k.backend.State()
+		glib.IdleAdd(func() {
+			k.reading = false
+			if k.destroyed {
+				return
+			}
+			if err != nil {
+				log.Println("Error reading the keyboard layout:", err)
+			} else {
+				k.show(state)
+			}
+			if k.stale {
+				k.stale = false
+				k.update()
+			}
+		})
+	}()
+}
+
+// show makes the label show state.
+func (k *keyboardIndicator) show(state keyboard.State) {
+	k.state = state
+
+	name := state.ActiveName
+	if state.Active >= 0 && state.Active < len(state.Layouts) {
+		name = k.layoutLabel(state.Layouts[state.Active])
+	} else if cfg, ok := k.configured(keyboard.Layout{Name: name}); ok && cfg.Label != "" {
+		name = cfg.Label
+	} else {
+		name = shortLayoutName(name)
+	}
+	k.label.SetText(name)
+	k.label.SetTooltipText(state.ActiveName)
+}
+
+// configured returns the configuration of a layout, if it has one.
+func (k *keyboardIndicator) configured(layout keyboard.Layout) (keyboardLayout, bool) {
+	for _, cfg := range k.opts.Layouts {
+		if cfg.Name != "" && (cfg.Name == layout.Name || cfg.Name == layout.Code) {
+			return cfg, true
+		}
+	}
+	return keyboardLayout{}, false
+}
+
+// layoutLabel returns the text shown for a layout: its configured label, its
+// code or the beginning of its name.
+func (k *keyboardIndicator) layoutLabel(layout keyboard.Layout) string {
+	if cfg, ok := k.configured(layout); ok && cfg.Label != "" {
+		return cfg.Label
+	}
+	if layout.Code != "" {
+		return strings.ToUpper(layout.Code)
+	}
+	return shortLayoutName(layout.Name)
+}
+
+func shortLayoutName(name string) string {
+	return strings.ToUpper(firstN(name, 2))
+}
+
+// choices returns the indices of the layouts to switch between, in the
+// order of the configuration.
+func (k *keyboardIndicator) choices() []int {
+	var choices []int
+	if len(k.opts.Layouts) == 0 {
+		for i := range k.state.Layouts {
+			choices = append(choices, i)
+		}
+		return choices
+	}
+
+	for _, cfg := range k.opts.Layouts {
+		for i, layout := range k.state.Layouts {
+			if (cfg.Name == layout.Name || cfg.Name == layout.Code) && !slices.Contains(choices, i) {
+				choices = append(choices, i)
+			}
+		}
+	}
+	return choices
+}
+
+// next switches to the layout after the active one.
+func (k *keyboardIndicator) next() {
+	choices := k.choices()
+	if len(choices) == 0 {
+		return
+	}
+
+	target := choices[0]
+	for i, index := range choices {
+		if index == k.state.Active {
+			target = choices[(i+1)%len(choices)]
+			break
+		}
+	}
+	k.setLayout(target)
+}
+
+// setLayout switches to the layout at index in the background. The backend
+// reports the change, which updates the label; until then the layout is
+// taken to be active, so that clicks in quick succession keep cycling.
+func (k *keyboardIndicator) setLayout(index int) {
+	k.state.Active = index
+	go func() {
+		if err := k.backend.SetLayout(index); err != nil {
+			log.Println("Error switching the keyboard layout:", err)
+		}
+	}()
+}
+
+func (k *keyboardIndicator) menuItems() []gtk.IMenuItem {
+	var items []gtk.IMenuItem
+	for _, index := range k.choices() {
+		layout := k.state.Layouts[index]
+		text := k.layoutLabel(layout)
+		if layout.Name != "" {
+			text += "  " + layout.Name
+		}
+
+		item, _ := gtk.CheckMenuItemNewWithLabel(text)
+		item.SetDrawAsRadio(true)
+		item.SetActive(index == k.state.Active)
+		item.Connect("activate", func() {
+			k.setLayout(index)
+		})
+		items = append(items, item)
+	}
+	return items
+}
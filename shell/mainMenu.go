@@ -1,160 +1,231 @@
-package main
-
-import (
-	"fmt"
-	"os"
-	"sort"
-
-	"github.com/AuruTeam/libxdg-go/desktopFiles"
-	"github.com/dlasky/gotk3-layershell/layershell"
-	"github.com/gotk3/gotk3/gdk"
-	"github.com/gotk3/gotk3/gtk"
-)
-
-// createAppGroup создает группу приложений
-func createAppGroup(apps []desktopFiles.DesktopFile) *gtk.Box {
-	group, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
-	for _, app := range apps {
-		buttonBox, _ := gtk.ButtonNew()
-		appBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
-		appBox.GetStyleContext().AddClass("mm_applist_app")
-
-		// Загрузка иконки приложения
-		if pixbuf, err := gdk.PixbufNewFromFileAtScale(app.Icon, 16, 16, true); err == nil {
-			icon, _ := gtk.ImageNewFromPixbuf(pixbuf)
-			appBox.PackStart(icon, false, false, 5)
-		}
-
-		label, _ := gtk.LabelNew(app.Name)
-		appBox.PackStart(label, false, false, 5)
-		buttonBox.Add(appBox)
-		buttonBox.Connect("clicked", func() {
-			fmt.Println("Clicked on", app.Name)
-			go desktopFiles.ExecuteDesktopFile(app, []string{}, "")
-		})
-		group.PackStart(buttonBox, false, false, 5)
-	}
-	return group
-}
-
-// createAppList создает список установленных приложений
-func createAppList() *gtk.ScrolledWindow {
-	scroll, _ := gtk.ScrolledWindowNew(nil, nil)
-	scroll.SetPolicy(gtk.POLICY_AUTOMATIC, gtk.POLICY_AUTOMATIC)
-	vbox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
-
-	apps, _ := desktopFiles.ListAllApplications()
-	validApps := make([]desktopFiles.DesktopFile, 0)
-
-	// Отфильтровываем скрытые приложения
-	for _, app := range apps {
-		if !app.NoDisplay {
-			validApps = append(validApps, app)
-		}
-	}
-
-	// Группировка приложений по первой букве имени
-	categories := make(map[string][]desktopFiles.DesktopFile)
-	for _, app := range validApps {
-		category := string([]rune(app.Name)[0]) // Получаем первую букву имени
-		categories[category] = append(categories[category], app)
-	}
-
-	// Сортировка категорий
-	sortedCategories := make([]string, 0, len(categories))
-	for cat := range categories {
-		sortedCategories = append(sortedCategories, cat)
-	}
-	sort.Strings(sortedCategories)
-
-	// Создание интерфейса для категорий
-	for _, category := range sortedCategories {
-		label, _ := gtk.LabelNew(fmt.Sprintf("<b>%s</b>", category))
-		label.SetUseMarkup(true)
-		label.SetXAlign(0)
-		vbox.PackStart(label, false, false, 5)
-
-		// Сортировка приложений в категории
-		sortedApps := categories[category]
-		sort.Slice(sortedApps, func(i, j int) bool {
-			return sortedApps[i].Name < sortedApps[j].Name
-		})
-
-		vbox.PackStart(createAppGroup(sortedApps), false, false, 5)
-	}
-
-	scroll.Add(vbox)
-	return scroll
-}
-
-// createMainMenu создает главное окно меню
-func createMainMenu() *gtk.Window {
-	win, _ := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
-	win.SetTitle("Main Menu")
-	win.SetDefaultSize(600, 600)
-	win.SetDecorated(false)
-	win.SetResizable(false)
-	win.SetTypeHint(gdk.WINDOW_TYPE_HINT_DOCK)
-
-	// Настройка LayerShell
-	layershell.InitForWindow(win)
-	layershell.SetNamespace(win, "miracleos")
-	layershell.SetAnchor(win, layershell.LAYER_SHELL_EDGE_BOTTOM, true)
-	layershell.SetLayer(win, layershell.LAYER_SHELL_LAYER_OVERLAY)
-
-	// Определение монитора
-	if disp, err := gdk.DisplayGetDefault(); err == nil {
-		if mon, err := disp.GetMonitor(0); err == nil {
-			layershell.SetMonitor(win, mon)
-		}
-	}
-
-	mainBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
-	mainBox.GetStyleContext().AddClass("mm_menu_m2")
-
-	// Верхняя панель с поиском
-	topBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
-	topBox.GetStyleContext().AddClass("mm_toppart")
-
-	searchEntry, _ := gtk.EntryNew()
-	searchEntry.SetPlaceholderText("Search Anything")
-	searchEntry.GetStyleContext().AddClass("mos-input")
-	topBox.PackStart(searchEntry, true, true, 5)
-
-	// Основная часть с вкладками
-	contentBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
-	appList := createAppList()
-	appList.SetSizeRequest(300, 600)
-
-	fastApps := createPlaceholder("Most Used")
-	fastApps.SetSizeRequest(300, 600)
-
-	otherTab := createPlaceholder("Other")
-	otherTab.SetSizeRequest(300, 600)
-
-	contentBox.PackStart(appList, false, false, 10)
-	contentBox.PackStart(fastApps, false, false, 10)
-	contentBox.PackStart(otherTab, false, false, 10)
-
-	mainBox.PackStart(topBox, false, false, 10)
-	mainBox.PackStart(contentBox, true, true, 10)
-
-	// Нижняя панель с пользователем и кнопками питания
-	bottomBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
-	bottomBox.PackStart(createUserInfo(), false, false, 10)
-	bottomBox.PackEnd(createPowerButtons(), false, false, 10)
-	bottomBox.GetStyleContext().AddClass("mm_bottompart")
-
-	mainBox.PackStart(bottomBox, false, false, 10)
-
-	win.Add(mainBox)
-	return win
-}
-
-func main() {
-	gtk.Init(nil)
-	win := createMainMenu()
-	win.Connect("destroy", gtk.MainQuit)
-	win.ShowAll()
-	gtk.Main()
-}
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"sort"
+
+	"github.com/AuruTeam/libxdg-go/desktopFiles"
+	"github.com/dlasky/gotk3-layershell/layershell"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// createAppGroup создает группу приложений
+func createAppGroup(apps []desktopFiles.DesktopFile) *gtk.Box {
+	group, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+	for _, app := range apps {
+		buttonBox, _ := gtk.ButtonNew()
+		appBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+		sc, _ := appBox.GetStyleContext()
+		sc.AddClass("mm_applist_app")
+
+		// Загрузка иконки приложения
+		if pixbuf, err := gdk.PixbufNewFromFileAtScale(app.Icon, 16, 16, true); err == nil {
+			icon, _ := gtk.ImageNewFromPixbuf(pixbuf)
+			appBox.PackStart(icon, false, false, 5)
+		}
+
+		label, _ := gtk.LabelNew(app.Name)
+		appBox.PackStart(label, false, false, 5)
+		buttonBox.Add(appBox)
+		buttonBox.Connect("clicked", func() {
+			fmt.Println("Clicked on", app.Name)
+			go desktopFiles.ExecuteDesktopFile(app, []string{}, "")
+		})
+		// Правый клик открывает меню закрепления на панели
+		buttonBox.Connect("button-press-event", func(_ *gtk.Button, ev *gdk.Event) bool {
+			if !isSecondaryClick(ev) {
+				return false
+			}
+			id, ok := appIDFor(app)
+			if !ok {
+				return false
+			}
+			popupMenu(ev, pinMenuItem(id))
+			return true
+		})
+		group.PackStart(buttonBox, false, false, 5)
+	}
+	return group
+}
+
+// createAppList создает список установленных приложений
+func createAppList() *gtk.ScrolledWindow {
+	scroll, _ := gtk.ScrolledWindowNew(nil, nil)
+	scroll.SetPolicy(gtk.POLICY_AUTOMATIC, gtk.POLICY_AUTOMATIC)
+	vbox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+
+	apps, _ := desktopFiles.ListAllApplications()
+	validApps := make([]desktopFiles.DesktopFile, 0)
+
+	// Отфильтровываем скрытые приложения
+	for _, app := range apps {
+		if !app.NoDisplay {
+			validApps = append(validApps, app)
+		}
+	}
+
+	// Группировка приложений по первой букве имени
+	categories := make(map[string][]desktopFiles.DesktopFile)
+	for _, app := range validApps {
+		category := string([]rune(app.Name)[0]) // Получаем первую букву имени
+		categories[category] = append(categories[category], app)
+	}
+
+	// Сортировка категорий
+	sortedCategories := make([]string, 0, len(categories))
+	for cat := range categories {
+		sortedCategories = append(sortedCategories, cat)
+	}
+	sort.Strings(sortedCategories)
+
+	// Создание интерфейса для категорий
+	for _, category := range sortedCategories {
+		label, _ := gtk.LabelNew(fmt.Sprintf("<b>%s</b>", category))
+		label.SetUseMarkup(true)
+		label.SetXAlign(0)
+		vbox.PackStart(label, false, false, 5)
+
+		// Сортировка приложений в категории
+		sortedApps := categories[category]
+		sort.Slice(sortedApps, func(i, j int) bool {
+			return sortedApps[i].Name < sortedApps[j].Name
+		})
+
+		vbox.PackStart(createAppGroup(sortedApps), false, false, 5)
+	}
+
+	scroll.Add(vbox)
+	return scroll
+}
+
+// createPlaceholder создает пустую вкладку с заголовком
+func createPlaceholder(title string) *gtk.Box {
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+	label, _ := gtk.LabelNew(fmt.Sprintf("<b>%s</b>", title))
+	label.SetUseMarkup(true)
+	label.SetXAlign(0)
+	box.PackStart(label, false, false, 5)
+	return box
+}
+
+// createUserInfo создает блок с именем текущего пользователя
+func createUserInfo() *gtk.Box {
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	sc, _ := box.GetStyleContext()
+	sc.AddClass("mm_user")
+
+	avatar, _ := gtk.ImageNewFromIconName("avatar-default-symbolic", gtk.ICON_SIZE_LARGE_TOOLBAR)
+	box.PackStart(avatar, false, false, 5)
+
+	name := "User"
+	if usr, err := user.Current(); err == nil {
+		name = usr.Username
+		if usr.Name != "" {
+			name = usr.Name
+		}
+	}
+	label, _ := gtk.LabelNew(name)
+	box.PackStart(label, false, false, 5)
+	return box
+}
+
+// createPowerButtons создает кнопки блокировки, перезагрузки и выключения
+func createPowerButtons() *gtk.Box {
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	buttons := []struct {
+		icon    string
+		command []string
+	}{
+		{"system-lock-screen-symbolic", []string{"loginctl", "lock-session"}},
+		{"system-reboot-symbolic", []string{"systemctl", "reboot"}},
+		{"system-shutdown-symbolic", []string{"systemctl", "poweroff"}},
+	}
+	for _, b := range buttons {
+		button, _ := gtk.ButtonNewFromIconName(b.icon, gtk.ICON_SIZE_LARGE_TOOLBAR)
+		sc, _ := button.GetStyleContext()
+		sc.AddClass("mm_power")
+		button.Connect("clicked", func() {
+			if err := exec.Command(b.command[0], b.command[1:]...).Start(); err != nil {
+				fmt.Println("Error running", b.command[0], err)
+			}
+		})
+		box.PackStart(button, false, false, 5)
+	}
+	return box
+}
+
+// CreateMainMenu создает главное окно меню на мониторе mon; если mon равен
+// nil, монитор выбирает композитор
+func CreateMainMenu(mon *gdk.Monitor) *gtk.Window {
+	return createMainMenu(mon, layershell.LAYER_SHELL_EDGE_BOTTOM)
+}
+
+// createMainMenu создает главное окно меню у края edge, к которому
+// прикреплена панель
+func createMainMenu(mon *gdk.Monitor, edge layershell.LayerShellEdgeFlags) *gtk.Window {
+	win, _ := gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	win.SetTitle("Main Menu")
+	win.SetDefaultSize(600, 600)
+	win.SetDecorated(false)
+	win.SetResizable(false)
+	win.SetTypeHint(gdk.WINDOW_TYPE_HINT_DOCK)
+
+	// Настройка LayerShell
+	layershell.InitForWindow(win)
+	layershell.SetNamespace(win, "miracleos")
+	layershell.SetAnchor(win, edge, true)
+	layershell.SetLayer(win, layershell.LAYER_SHELL_LAYER_OVERLAY)
+
+	// Определение монитора
+	if mon != nil {
+		layershell.SetMonitor(win, mon)
+	}
+
+	mainBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+	sc, _ := mainBox.GetStyleContext()
+	sc.AddClass("mm_menu_m2")
+
+	// Верхняя панель с поиском
+	topBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
+	sc, _ = topBox.GetStyleContext()
+	sc.AddClass("mm_toppart")
+
+	searchEntry, _ := gtk.EntryNew()
+	searchEntry.SetPlaceholderText("Search Anything")
+	sc, _ = searchEntry.GetStyleContext()
+	sc.AddClass("mos-input")
+	topBox.PackStart(searchEntry, true, true, 5)
+
+	// Основная часть с вкладками
+	contentBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
+	appList := createAppList()
+	appList.SetSizeRequest(300, 600)
+
+	fastApps := createPlaceholder("Most Used")
+	fastApps.SetSizeRequest(300, 600)
+
+	otherTab := createPlaceholder("Other")
+	otherTab.SetSizeRequest(300, 600)
+
+	contentBox.PackStart(appList, false, false, 10)
+	contentBox.PackStart(fastApps, false, false, 10)
+	contentBox.PackStart(otherTab, false, false, 10)
+
+	mainBox.PackStart(topBox, false, false, 10)
+	mainBox.PackStart(contentBox, true, true, 10)
+
+	// Нижняя панель с пользователем и кнопками питания
+	bottomBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
+	bottomBox.PackStart(createUserInfo(), false, false, 10)
+	bottomBox.PackEnd(createPowerButtons(), false, false, 10)
+	sc, _ = bottomBox.GetStyleContext()
+	sc.AddClass("mm_bottompart")
+
+	mainBox.PackStart(bottomBox, false, false, 10)
+
+	win.Add(mainBox)
+	return win
+}
@@ -0,0 +1,136 @@
+package shell
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/notification"
+	"github.com/AuruTeam/desktop/status"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// Module is a widget on the bar together with what keeps it up to date.
+type Module struct {
+	Widget  gtk.IWidget
+	Sources []ModuleSource
+}
+
+// ModuleSource tells when a module has to refresh its widget. Update is
+// called on the GTK main loop once when the module is shown and then every
+// time Status reports a change, or every Interval if Status is nil. The
+// updates stop when the widget is destroyed.
+type ModuleSource struct {
+	Status   status.Source
+	Interval time.Duration
+	Update   func()
+}
+
+// ModuleContext describes the bar a module is created for.
+type ModuleContext struct {
+	// Monitor is the monitor of the bar.
+	Monitor       *gdk.Monitor
+	Notifications *notification.Daemon
+	Bar           config.Bar
+
+	layout barLayout
+}
+
+// Orientation returns the direction the bar runs in.
+func (ctx ModuleContext) Orientation() gtk.Orientation {
+	return ctx.layout.orientation
+}
+
+// PopoverPosition returns the side popovers of the bar open on.
+func (ctx ModuleContext) PopoverPosition() gtk.PositionType {
+	return ctx.layout.popover
+}
+
+// ModuleFunc creates a module for one bar. options is the layout entry of
+// the module; config.Module.Decode reads its options.
+type ModuleFunc func(ctx ModuleContext, options config.Module) (*Module, error)
+
+// modules are the modules the bar layout can refer to, by type.
+var modules = map[string]ModuleFunc{
+	"taskbar":       newTaskbarModule,
+	"launcher":      newLauncherModule,
+	"keyboard":      newKeyboardModule,
+	"status":        newStatusModule,
+	"clock":         newClockModule,
+	"notifications": newNotificationsModule,
+	"script":        newScriptModule,
+	"tray":          newTrayModule,
+}
+
+// RegisterModule makes a module available to the bar layout under typ,
+// replacing any module of the same type. It must be called before the bars
+// are created.
+func RegisterModule(typ string, create ModuleFunc) {
+	modules[typ] = create
+}
+
+// createSection creates the widgets of the modules in one section of the
+// bar. Modules that fail are left out.
+func createSection(ctx ModuleContext, entries []config.Module, align gtk.Align, classes ...string) *gtk.Box {
+	box, _ := gtk.BoxNew(ctx.layout.orientation, 0)
+	ctx.layout.align(box, align)
+	sc, _ := box.GetStyleContext()
+	for _, class := range classes {
+		sc.AddClass(class)
+	}
+	for _, entry := range entries {
+		m, err := createModule(ctx, entry)
+		if err != nil {
+			log.Println("Error creating bar module:", err)
+			continue
+		}
+		box.PackStart(m.Widget, false, false, 0)
+		startModule(entry.Type, m)
+	}
+	return box
+}
+
+func createModule(ctx ModuleContext, entry config.Module) (*Module, error) {
+	create, ok := modules[entry.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown module %q", entry.Type)
+	}
+	m, err := create(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", entry.Type, err)
+	}
+	return m, nil
+}
+
+// startModule runs the updates of m until its widget is destroyed.
+func startModule(name string, m *Module) {
+	var stops []func()
+	for _, src := range m.Sources {
+		switch {
+		case src.Status != nil:
+			stops = append(stops, watchStatus(name, src.Status, src.Update))
+		case src.Interval > 0:
+			stops = append(stops, pollModule(src.Interval, src.Update))
+		default:
+			src.Update()
+		}
+	}
+
+	m.Widget.ToWidget().Connect("destroy", func() {
+		for _, stop := range stops {
+			stop()
+		}
+	})
+}
+
+func pollModule(interval time.Duration, update func()) func() {
+	update()
+	handle := glib.TimeoutAdd(uint(interval.Milliseconds()), func() bool {
+		update()
+		return true
+	})
+	return func() { glib.SourceRemove(handle) }
+}
@@ -0,0 +1,519 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/AuruTeam/desktop/network"
+	"github.com/AuruTeam/desktoplib/networkManagerHandler"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// networkControl is the network icon of the status module. Clicking it
+// opens a popover with the active connections, the Wi-Fi networks in range
+// and the other saved connections.
+type networkControl struct {
+	client *network.Client
+	state  network.State
+
+	button  *gtk.Button
+	image   *gtk.Image
+	popover *gtk.Popover
+
+	wifi     *gtk.Switch
+	errors   *gtk.Label
+	active   *gtk.Box
+	networks *gtk.Box
+	saved    *gtk.Box
+
+	// form asks for the passphrase of a new Wi-Fi network.
+	form       *gtk.Box
+	formLabel  *gtk.Label
+	formError  *gtk.Label
+	passphrase *gtk.Entry
+	formAP     network.AccessPoint
+
+	// reading is set while the state is read in the background, and stale
+	// if it changed again meanwhile.
+	reading, stale bool
+	destroyed      bool
+
+	// updating is set while the widgets show a new state, so that their
+	// signals are not taken for the user.
+	updating bool
+}
+
+// newNetworkControl creates the network icon with its popover.
+func newNetworkControl(ctx ModuleContext, client *network.Client) *networkControl {
+	n := &networkControl{client: client}
+	startNetworkAgent()
+
+	n.button, _ = gtk.ButtonNew()
+	n.button.SetRelief(gtk.RELIEF_NONE)
+	n.image, _ = gtk.ImageNew()
+	sc, _ := n.image.GetStyleContext()
+	sc.AddClass("network")
+	n.button.Add(n.image)
+
+	n.popover, _ = gtk.PopoverNew(n.button)
+	n.popover.SetPosition(ctx.PopoverPosition())
+	sc, _ = n.popover.GetStyleContext()
+	sc.AddClass("network-menu")
+	n.popover.Add(n.createMenu())
+
+	n.button.Connect("clicked", func() {
+		if n.popover.IsVisible() {
+			n.popover.Popdown()
+			return
+		}
+		n.form.Hide()
+		n.fill()
+		n.popover.ShowAll()
+		n.popover.Popup()
+		state := n.state
+		go func() { logNetworkError("scan for Wi-Fi networks", n.client.Scan(state)) }()
+	})
+	n.button.Connect("destroy", func() {
+		n.destroyed = true
+		n.popover.Destroy()
+		n.client.Close()
+	})
+	return n
+}
+
+func (n *networkControl) createMenu() *gtk.Box {
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+
+	header, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
+	wifiLabel, _ := gtk.LabelNew("Wi-Fi")
+	sc, _ := wifiLabel.GetStyleContext()
+	sc.AddClass("h2")
+	n.wifi, _ = gtk.SwitchNew()
+	n.wifi.Connect("notify::active", func() {
+		if n.updating {
+			return
+		}
+		enabled := n.wifi.GetActive()
+		go func() { logNetworkError("switch Wi-Fi", n.client.SetWirelessEnabled(enabled)) }()
+	})
+	header.PackStart(wifiLabel, false, false, 0)
+	header.PackEnd(n.wifi, false, false, 0)
+	box.PackStart(header, false, false, 0)
+
+	// Shows why the last connection attempt failed.
+	n.errors = newNetworkError()
+	box.PackStart(n.errors, false, false, 0)
+
+	n.active = newNetworkSection()
+	box.PackStart(n.active, false, false, 0)
+
+	n.networks = newNetworkSection()
+	scrolled, _ := gtk.ScrolledWindowNew(nil, nil)
+	scrolled.SetPolicy(gtk.POLICY_NEVER, gtk.POLICY_AUTOMATIC)
+	scrolled.SetPropagateNaturalHeight(true)
+	scrolled.SetMaxContentHeight(300)
+	scrolled.Add(n.networks)
+	box.PackStart(scrolled, false, false, 0)
+
+	n.form, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+	n.form.SetNoShowAll(true)
+	sc, _ = n.form.GetStyleContext()
+	sc.AddClass("network-passphrase")
+	n.formLabel, _ = gtk.LabelNew("")
+	n.formLabel.SetXAlign(0)
+	n.formError = newNetworkError()
+	n.passphrase, _ = gtk.EntryNew()
+	n.passphrase.SetVisibility(false)
+	n.passphrase.SetInputPurpose(gtk.INPUT_PURPOSE_PASSWORD)
+	n.passphrase.Connect("activate", n.submitPassphrase)
+	buttons, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	cancel, _ := gtk.ButtonNewWithLabel("Cancel")
+	cancel.Connect("clicked", func() { n.form.Hide() })
+	connect, _ := gtk.ButtonNewWithLabel("Connect")
+	connect.Connect("clicked", n.submitPassphrase)
+	buttons.PackEnd(connect, false, false, 0)
+	buttons.PackEnd(cancel, false, false, 0)
+	n.form.PackStart(n.formLabel, false, false, 0)
+	n.form.PackStart(n.passphrase, false, false, 0)
+	n.form.PackStart(n.formError, false, false, 0)
+	n.form.PackStart(buttons, false, false, 0)
+	box.PackStart(n.form, false, false, 0)
+
+	n.saved = newNetworkSection()
+	box.PackStart(n.saved, false, false, 0)
+	return box
+}
+
+func newNetworkError() *gtk.Label {
+	label, _ := gtk.LabelNew("")
+	label.SetXAlign(0)
+	label.SetLineWrap(true)
+	label.SetMaxWidthChars(40)
+	label.SetNoShowAll(true)
+	sc, _ := label.GetStyleContext()
+	sc.AddClass("network-error")
+	return label
+}
+
+func newNetworkSection() *gtk.Box {
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 2)
+	sc, _ := box.GetStyleContext()
+	sc.AddClass("network-section")
+	return box
+}
+
+// update refreshes the icon, and the popover if it is open, from
+// NetworkManager. The state is read in the background; changes that come in
+// meanwhile are read once it is done.
+func (n *networkControl) update() {
+	if n.reading {
+		n.stale = true
+		return
+	}
+	n.reading = true
+
+	go func() {
+		icon, iconErr := networkManagerHandler.GetNetworkIcon()
+		state, err := n.client.State()
+		glib.IdleAdd(func() {
+			n.reading = false
+			if n.destroyed {
+				return
+			}
+			if iconErr == nil {
+				n.image.SetFromIconName(icon, gtk.ICON_SIZE_BUTTON)
+			}
+			if err != nil {
+				log.Println("Error reading the network state:", err)
+			} else {
+				n.show(state)
+			}
+			if n.stale {
+				n.stale = false
+				n.update()
+			}
+		})
+	}()
+}
+
+// show makes the icon and the popover show state.
+func (n *networkControl) show(state network.State) {
+	n.state = state
+
+	var tooltip string
+	for _, active := range state.Active {
+		if tooltip != "" {
+			tooltip += "\n"
+		}
+		tooltip += active.ID
+	}
+	if tooltip == "" {
+		tooltip = "Disconnected"
+	}
+	n.button.SetTooltipText(tooltip)
+
+	if n.popover.IsVisible() {
+		n.fill()
+		n.popover.ShowAll()
+	}
+}
+
+// fill rebuilds the lists of the popover.
+func (n *networkControl) fill() {
+	n.updating = true
+	n.wifi.SetActive(n.state.WirelessEnabled)
+	n.updating = false
+
+	clearContainer(&n.active.Container)
+	clearContainer(&n.networks.Container)
+	clearContainer(&n.saved.Container)
+
+	activeUUIDs := make(map[string]bool)
+	for _, active := range n.state.Active {
+		activeUUIDs[active.UUID] = true
+		n.active.PackStart(n.activeRow(active), false, false, 0)
+	}
+
+	if n.state.WirelessEnabled {
+		for _, ap := range n.state.AccessPoints {
+			if !ap.Active {
+				n.networks.PackStart(n.accessPointRow(ap), false, false, 0)
+			}
+		}
+	}
+
+	for _, conn := range n.state.Connections {
+		// Wi-Fi connections are offered through their networks.
+		if conn.Type == network.TypeWireless || activeUUIDs[conn.UUID] {
+			continue
+		}
+		n.saved.PackStart(n.savedRow(conn), false, false, 0)
+	}
+}
+
+// activeRow shows an active connection with a button to disconnect it.
+func (n *networkControl) activeRow(active network.ActiveConnection) *gtk.Box {
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	sc, _ := row.GetStyleContext()
+	sc.AddClass("network-active")
+
+	icon := connectionIcon(active.Type)
+	var strength uint8
+	for _, ap := range n.state.AccessPoints {
+		if ap.Active && ap.SSID == active.ID {
+			strength = ap.Strength
+			icon = signalIcon(strength)
+		}
+	}
+	image, _ := gtk.ImageNewFromIconName(icon, gtk.ICON_SIZE_BUTTON)
+
+	text := active.ID
+	if active.State == network.Activating {
+		text = fmt.Sprintf("%s (connecting…)", active.ID)
+	}
+	label := ellipsizedLabel(text)
+
+	disconnect, _ := gtk.ButtonNewWithLabel("Disconnect")
+	disconnect.SetRelief(gtk.RELIEF_NONE)
+	disconnect.Connect("clicked", func() {
+		go func() { logNetworkError("disconnect", n.client.Deactivate(active)) }()
+	})
+
+	row.PackStart(image, false, false, 0)
+	row.PackStart(label, true, true, 0)
+	row.PackEnd(disconnect, false, false, 0)
+	return row
+}
+
+// accessPointRow shows a Wi-Fi network. Clicking it connects to it, asking
+// for the passphrase of new secured networks.
+func (n *networkControl) accessPointRow(ap network.AccessPoint) *gtk.Button {
+	button, _ := gtk.ButtonNew()
+	button.SetRelief(gtk.RELIEF_NONE)
+	sc, _ := button.GetStyleContext()
+	sc.AddClass("network-ap")
+
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	signal, _ := gtk.ImageNewFromIconName(signalIcon(ap.Strength), gtk.ICON_SIZE_BUTTON)
+	row.PackStart(signal, false, false, 0)
+	row.PackStart(ellipsizedLabel(ap.SSID), true, true, 0)
+	if ap.Secured() {
+		lock, _ := gtk.ImageNewFromIconName("network-wireless-encrypted-symbolic", gtk.ICON_SIZE_BUTTON)
+		row.PackEnd(lock, false, false, 0)
+	}
+	button.Add(row)
+
+	tooltip := fmt.Sprintf("Signal %d%%", ap.Strength)
+	if ap.Secured() {
+		tooltip += ", " + ap.Security
+	}
+	if ap.Connection != "" {
+		tooltip += ", saved"
+	}
+	button.SetTooltipText(tooltip)
+
+	if ap.Enterprise() && ap.Connection == "" {
+		// These need certificates and identities that are set up in
+		// the network settings.
+		button.SetSensitive(false)
+		button.SetTooltipText(tooltip + "\nSet this network up in the network settings first.")
+	}
+
+	button.Connect("clicked", func() {
+		if ap.Secured() && ap.Connection == "" {
+			n.askPassphrase(ap)
+			return
+		}
+		n.connect(ap.SSID, func() error { return n.client.ConnectAccessPoint(ap, "") })
+	})
+	return button
+}
+
+// savedRow shows a saved wired, VPN or other connection to activate.
+func (n *networkControl) savedRow(conn network.Connection) *gtk.Button {
+	button, _ := gtk.ButtonNew()
+	button.SetRelief(gtk.RELIEF_NONE)
+	sc, _ := button.GetStyleContext()
+	sc.AddClass("network-saved")
+
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	image, _ := gtk.ImageNewFromIconName(connectionIcon(conn.Type), gtk.ICON_SIZE_BUTTON)
+	row.PackStart(image, false, false, 0)
+	row.PackStart(ellipsizedLabel(conn.ID), true, true, 0)
+	button.Add(row)
+
+	button.Connect("clicked", func() {
+		n.connect(conn.ID, func() error { return n.client.Activate(conn) })
+	})
+	return button
+}
+
+func (n *networkControl) askPassphrase(ap network.AccessPoint) {
+	n.formAP = ap
+	n.formLabel.SetText(fmt.Sprintf("Passphrase for “%s”", ap.SSID))
+	n.passphrase.SetText("")
+	n.formError.Hide()
+	n.form.ShowAll()
+	n.passphrase.GrabFocus()
+}
+
+func (n *networkControl) submitPassphrase() {
+	passphrase, _ := n.passphrase.GetText()
+	if passphrase == "" {
+		return
+	}
+	ap := n.formAP
+	if !ap.ValidPassphrase(passphrase) {
+		n.formError.SetText("WPA passphrases have 8 to 63 characters.")
+		n.formError.Show()
+		return
+	}
+	n.passphrase.SetText("")
+	n.form.Hide()
+	n.connect(ap.SSID, func() error { return n.client.ConnectAccessPoint(ap, passphrase) })
+}
+
+// connect runs connect in the background. NetworkManager refuses invalid
+// settings right away, so its error is shown in the popover; later failures
+// show up as the connection state changes.
+func (n *networkControl) connect(name string, connect func() error) {
+	n.errors.Hide()
+	go func() {
+		err := connect()
+		if err == nil {
+			return
+		}
+		logNetworkError("connect to "+name, err)
+		glib.IdleAdd(func() {
+			if n.destroyed {
+				return
+			}
+			n.errors.SetText(fmt.Sprintf("Could not connect to “%s”: %v", name, err))
+			n.errors.Show()
+		})
+	}()
+}
+
+// signalIcon returns the icon for a Wi-Fi signal strength in percent.
+func signalIcon(strength uint8) string {
+	switch {
+	case strength > 75:
+		return "network-wireless-signal-excellent-symbolic"
+	case strength > 50:
+		return "network-wireless-signal-good-symbolic"
+	case strength > 25:
+		return "network-wireless-signal-ok-symbolic"
+	case strength > 0:
+		return "network-wireless-signal-weak-symbolic"
+	}
+	return "network-wireless-signal-none-symbolic"
+}
+
+func connectionIcon(connType string) string {
+	switch connType {
+	case network.TypeEthernet:
+		return "network-wired-symbolic"
+	case network.TypeWireless:
+		return "network-wireless-symbolic"
+	case network.TypeVPN, network.TypeWireGuard:
+		return "network-vpn-symbolic"
+	}
+	return "network-workgroup-symbolic"
+}
+
+func logNetworkError(action string, err error) {
+	if err != nil {
+		log.Printf("Failed to %s: %v", action, err)
+	}
+}
+
+// networkAgent answers the secret requests of NetworkManager for the whole
+// process, so that only one agent is registered however many bars there are.
+var networkAgent *network.Agent
+
+func startNetworkAgent() {
+	if networkAgent != nil {
+		return
+	}
+	agent, err := network.RegisterAgent(promptSecret)
+	if err != nil {
+		log.Println("Error registering the network secret agent:", err)
+		return
+	}
+	networkAgent = agent
+}
+
+type secretAnswer struct {
+	secret string
+	ok     bool
+}
+
+// promptSecret asks for the secret of a connection in a dialog. It is called
+// by the secret agent outside of the GTK main loop and waits for the answer.
+func promptSecret(ctx context.Context, req network.SecretRequest) (string, bool) {
+	answers := make(chan secretAnswer, 1)
+	var dialog *gtk.Dialog
+	glib.IdleAdd(func() {
+		dialog = newSecretDialog(req, func(answer secretAnswer) {
+			select {
+			case answers <- answer:
+			default:
+			}
+		})
+	})
+
+	select {
+	case answer := <-answers:
+		return answer.secret, answer.ok
+	case <-ctx.Done():
+		// Runs after the dialog was created, as idle callbacks run in
+		// order.
+		glib.IdleAdd(func() { dialog.Destroy() })
+		return "", false
+	}
+}
+
+func newSecretDialog(req network.SecretRequest, answer func(secretAnswer)) *gtk.Dialog {
+	dialog, _ := gtk.DialogNew()
+	dialog.SetTitle("Network authentication")
+	dialog.SetKeepAbove(true)
+	dialog.SetResizable(false)
+	sc, _ := dialog.GetStyleContext()
+	sc.AddClass("network-secret")
+
+	name := req.SSID
+	if name == "" {
+		name = req.Connection
+	}
+	text := fmt.Sprintf("Passphrase for “%s”", name)
+	if req.Retry {
+		text = fmt.Sprintf("The passphrase for “%s” did not work. Try again:", name)
+	}
+	label, _ := gtk.LabelNew(text)
+	label.SetXAlign(0)
+	entry, _ := gtk.EntryNew()
+	entry.SetVisibility(false)
+	entry.SetInputPurpose(gtk.INPUT_PURPOSE_PASSWORD)
+	entry.SetActivatesDefault(true)
+
+	content, _ := dialog.GetContentArea()
+	content.SetSpacing(10)
+	content.PackStart(label, false, false, 0)
+	content.PackStart(entry, false, false, 0)
+
+	dialog.AddButton("Cancel", gtk.RESPONSE_CANCEL)
+	dialog.AddButton("Connect", gtk.RESPONSE_OK)
+	dialog.SetDefaultResponse(gtk.RESPONSE_OK)
+	dialog.Connect("response", func(_ *gtk.Dialog, response gtk.ResponseType) {
+		secret, _ := entry.GetText()
+		answer(secretAnswer{secret: secret, ok: response == gtk.RESPONSE_OK && secret != ""})
+		dialog.Destroy()
+	})
+	// Closing the window counts as cancelling.
+	dialog.Connect("destroy", func() { answer(secretAnswer{}) })
+
+	dialog.ShowAll()
+	return dialog
+}
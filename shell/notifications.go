@@ -0,0 +1,360 @@
+package shell
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/AuruTeam/desktop/clock"
+	"github.com/AuruTeam/desktop/notification"
+	"github.com/AuruTeam/desktop/toplevel"
+	"github.com/dlasky/gotk3-layershell/layershell"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// notificationImageSize is the largest size of notification images, in
+// pixels.
+const notificationImageSize = 48
+
+// Function to create a single notification box. Clicking it invokes the
+// default action of the notification, if it has one.
+func createNotification(nt *notification.Notification, nDaemon *notification.Daemon) *gtk.EventBox {
+	notificationBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 15)
+	sc, _ := notificationBox.GetStyleContext()
+	sc.AddClass("ntf_main_div")
+	// Themes can style notifications by urgency, and by category such as
+	// email.arrived, which becomes category-email-arrived.
+	sc.AddClass("urgency-" + nt.Urgency().String())
+	if category := nt.Category(); category != "" {
+		sc.AddClass("category-" + strings.ReplaceAll(category, ".", "-"))
+	}
+
+	// Create top bar with app icon, title, and close button
+	ntfTopBar, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 15)
+	sc, _ = ntfTopBar.GetStyleContext()
+	sc.AddClass("ntf_top_bar")
+
+	ntfTopBarText, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 15)
+	sc, _ = ntfTopBarText.GetStyleContext()
+	sc.AddClass("nf_topbar_text")
+
+	ntfTopBarImage, _ := gtk.ImageNewFromIconName(nt.AppIcon, gtk.ICON_SIZE_LARGE_TOOLBAR)
+	ntfTopBarTextLabel, _ := gtk.LabelNew(nt.AppName)
+
+	ntfTopBarDeleteButton, _ := gtk.ButtonNewWithLabel("✖")
+	sc, _ = ntfTopBarDeleteButton.GetStyleContext()
+	sc.AddClass("button")
+
+	ntfTopBarDeleteButton.Connect("clicked", func() {
+		nDaemon.Close(nt.ID, notification.ReasonDismissed)
+	})
+
+	ntfTopBarText.PackStart(ntfTopBarImage, false, false, 0)
+	ntfTopBarText.PackStart(ntfTopBarTextLabel, false, false, 0)
+	ntfTopBar.PackStart(ntfTopBarText, false, false, 0)
+	ntfTopBar.PackEnd(ntfTopBarDeleteButton, false, false, 0)
+	notificationBox.PackStart(ntfTopBar, false, false, 0)
+
+	// Notification content
+	notificationContent, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+	sc, _ = notificationContent.GetStyleContext()
+	sc.AddClass("ntf_text_contents")
+
+	if nt.Summary != "" {
+		notificationSummary, _ := gtk.LabelNew(nt.Summary)
+		notificationSummary.SetXAlign(0)
+		sc, _ = notificationSummary.GetStyleContext()
+		sc.AddClass("h2")
+		notificationContent.PackStart(notificationSummary, false, false, 0)
+	}
+
+	if nt.Body != "" {
+		notificationBody, _ := gtk.LabelNew(nt.Body)
+		notificationBody.SetXAlign(0)
+		notificationContent.PackStart(notificationBody, false, false, 0)
+	}
+
+	// Notifications are not tied to a clock module, so their time follows
+	// the locale of the environment.
+	locale := clock.LookupLocale("")
+	timeLabel, _ := gtk.LabelNew(locale.Format(nt.Timestamp, hoursMinutes(locale.Hour12)))
+	timeLabel.SetXAlign(1)
+	sc, _ = timeLabel.GetStyleContext()
+	sc.AddClass("h4")
+	notificationContent.PackEnd(timeLabel, false, false, 0)
+
+	if progress, ok := nt.Progress(); ok {
+		progressBar, _ := gtk.ProgressBarNew()
+		progressBar.SetFraction(float64(progress) / 100)
+		sc, _ = progressBar.GetStyleContext()
+		sc.AddClass("ntf_progress")
+		notificationContent.PackStart(progressBar, false, false, 0)
+	}
+
+	// The image of the notification, if any, goes next to its text
+	ntfBody, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 15)
+	if image := notificationImage(nt); image != nil {
+		image.SetVAlign(gtk.ALIGN_START)
+		sc, _ = image.GetStyleContext()
+		sc.AddClass("ntf_main_image")
+		ntfBody.PackStart(image, false, false, 0)
+	}
+	ntfBody.PackStart(notificationContent, true, true, 0)
+	notificationBox.PackStart(ntfBody, false, false, 0)
+
+	// Action buttons
+	actionBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 0)
+	sc, _ = actionBox.GetStyleContext()
+	sc.AddClass("linked")
+	sc.AddClass("ntf_actions")
+	hasDefault, hasButtons := false, false
+	for _, action := range nt.Actions {
+		if action.Key == notification.DefaultAction {
+			hasDefault = true
+			continue
+		}
+		hasButtons = true
+		actionButton, _ := gtk.ButtonNewWithLabel(action.Label)
+		actionButton.Connect("clicked", func() {
+			invokeNotificationAction(nDaemon, nt, action.Key)
+		})
+		actionBox.PackStart(actionButton, true, true, 0)
+	}
+	if hasButtons {
+		notificationBox.PackStart(actionBox, false, false, 0)
+	}
+
+	eventBox, _ := gtk.EventBoxNew()
+	eventBox.Add(notificationBox)
+	if hasDefault {
+		eventBox.Connect("button-release-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+			if gdk.EventButtonNewFromEvent(ev).Button() != gdk.BUTTON_PRIMARY {
+				return false
+			}
+			invokeNotificationAction(nDaemon, nt, notification.DefaultAction)
+			return true
+		})
+	}
+	return eventBox
+}
+
+// notificationImage returns the image a notification comes with, from raw
+// image data, a file or an icon name, or nil if it has none.
+func notificationImage(nt *notification.Notification) *gtk.Image {
+	if data, ok := nt.Image(); ok {
+		pixbuf, err := pixbufFromData(data.Data, data.Channels == 4, data.Width, data.Height, data.RowStride)
+		if err == nil {
+			if data.Width > notificationImageSize || data.Height > notificationImageSize {
+				pixbuf = scalePixbuf(pixbuf, notificationImageSize, notificationImageSize)
+			}
+			image, _ := gtk.ImageNewFromPixbuf(pixbuf)
+			return image
+		}
+	}
+
+	path := nt.ImagePath()
+	if filepath.IsAbs(path) {
+		pixbuf, err := gdk.PixbufNewFromFileAtScale(path, notificationImageSize, notificationImageSize, true)
+		if err != nil {
+			log.Println("Failed to load notification image:", err)
+			return nil
+		}
+		image, _ := gtk.ImageNewFromPixbuf(pixbuf)
+		return image
+	} else if path != "" {
+		image, _ := gtk.ImageNewFromIconName(path, gtk.ICON_SIZE_DIALOG)
+		image.SetPixelSize(notificationImageSize)
+		return image
+	}
+	return nil
+}
+
+// invokeNotificationAction invokes an action of a notification. The
+// application gets an activation token along with it, so that it can focus
+// its window.
+func invokeNotificationAction(nDaemon *notification.Daemon, nt *notification.Notification, key string) {
+	go func() {
+		token, err := toplevel.ActivationToken(nt.DesktopEntry())
+		if err != nil {
+			log.Println("No activation token for the notification action:", err)
+		}
+		nDaemon.InvokeAction(nt.ID, key, token)
+	}()
+}
+
+// notificationPanel lists the notifications of the daemon and follows them
+// as they are added, replaced and closed. Transient notifications are only
+// shown as popups.
+type notificationPanel struct {
+	daemon *notification.Daemon
+
+	win   *gtk.Window
+	title *gtk.Label
+	list  *gtk.Box
+	// rows holds the row of every listed notification. Rows slide in and
+	// out of the list.
+	rows map[uint32]*gtk.Revealer
+
+	destroyed bool
+}
+
+// Function to create the title bar of the notification panel
+func (p *notificationPanel) createTitle() *gtk.Box {
+	tBox, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 10)
+	p.title, _ = gtk.LabelNew("")
+	sc, _ := p.title.GetStyleContext()
+	sc.AddClass("h1")
+
+	closeAllButton, _ := gtk.ButtonNewWithLabel("Clear all")
+	sc, _ = closeAllButton.GetStyleContext()
+	sc.AddClass("button")
+
+	closeAllButton.Connect("clicked", func() {
+		for _, elem := range p.daemon.Notifications() {
+			p.daemon.Close(elem.ID, notification.ReasonDismissed)
+		}
+	})
+
+	tBox.PackStart(p.title, false, false, 0)
+	tBox.PackEnd(closeAllButton, false, false, 0)
+	return tBox
+}
+
+// CreateNotificationBar creates the notification panel. It stays hidden
+// until it is shown, and hides itself once the last notification is closed.
+func CreateNotificationBar(nDaemon *notification.Daemon) *gtk.Window {
+	p := &notificationPanel{daemon: nDaemon, rows: make(map[uint32]*gtk.Revealer)}
+
+	p.win, _ = gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	p.win.SetTitle("Notification Bar")
+	p.win.SetDecorated(false)
+	p.win.SetResizable(false)
+
+	// Setup window as a top-layer shell (like KDE Plasma/GNOME Shell)
+	layershell.InitForWindow(p.win)
+	layershell.SetNamespace(p.win, "miracleos")
+	layershell.SetLayer(p.win, layershell.LAYER_SHELL_LAYER_TOP)
+	layershell.SetAnchor(p.win, layershell.LAYER_SHELL_EDGE_RIGHT, true)
+	layershell.SetMargin(p.win, layershell.LAYER_SHELL_EDGE_TOP, 10)
+
+	mBox, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+	mBox.PackStart(p.createTitle(), false, false, 0)
+
+	p.list, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	scroll, _ := gtk.ScrolledWindowNew(nil, nil)
+	scroll.SetPolicy(gtk.POLICY_NEVER, gtk.POLICY_AUTOMATIC)
+	scroll.SetPropagateNaturalHeight(true)
+	scroll.SetMaxContentHeight(700)
+	scroll.Add(p.list)
+	mBox.PackStart(scroll, true, true, 0)
+
+	// Populate notifications
+	for _, nt := range nDaemon.Notifications() {
+		p.add(nt)
+	}
+	p.updateTitle()
+
+	stop := nDaemon.Subscribe(func(e notification.Event) {
+		glib.IdleAdd(func() {
+			if !p.destroyed {
+				p.handle(e)
+			}
+		})
+	})
+	p.win.Connect("destroy", func() {
+		p.destroyed = true
+		stop()
+	})
+
+	p.win.Add(mBox)
+	mBox.ShowAll()
+	return p.win
+}
+
+func (p *notificationPanel) handle(e notification.Event) {
+	switch e.Kind {
+	case notification.Added:
+		p.add(e.Notification)
+	case notification.Replaced:
+		if row, ok := p.rows[e.Notification.ID]; ok {
+			if child, err := row.GetChild(); err == nil && child != nil {
+				child.ToWidget().Destroy()
+			}
+			card := createNotification(&e.Notification, p.daemon)
+			card.ShowAll()
+			row.Add(card)
+		} else {
+			p.add(e.Notification)
+		}
+	case notification.Closed:
+		p.remove(e.Notification.ID)
+	}
+	p.updateTitle()
+}
+
+// add slides the row of a new notification in at the top of the list.
+func (p *notificationPanel) add(nt notification.Notification) {
+	if nt.Transient() {
+		return
+	}
+	row, _ := gtk.RevealerNew()
+	row.SetTransitionType(gtk.REVEALER_TRANSITION_TYPE_SLIDE_DOWN)
+	row.SetTransitionDuration(250)
+	row.Add(createNotification(&nt, p.daemon))
+	row.ShowAll()
+
+	p.list.PackStart(row, false, false, 0)
+	p.list.ReorderChild(row, 0)
+	p.rows[nt.ID] = row
+	row.SetRevealChild(true)
+}
+
+// remove slides the row of a closed notification out of the list.
+func (p *notificationPanel) remove(id uint32) {
+	row, ok := p.rows[id]
+	if !ok {
+		return
+	}
+	delete(p.rows, id)
+
+	row.SetTransitionType(gtk.REVEALER_TRANSITION_TYPE_SLIDE_UP)
+	// Hidden revealers skip the transition and report it done right
+	// away.
+	row.Connect("notify::child-revealed", func() {
+		if row.GetChildRevealed() {
+			return
+		}
+		row.Destroy()
+		if len(p.rows) == 0 {
+			p.win.Hide()
+		}
+		// Let the window shrink to the rows that are left.
+		p.win.Resize(1, 1)
+	})
+	row.SetRevealChild(false)
+}
+
+// listedNotifications returns how many notifications the notification
+// panel lists.
+func listedNotifications(nDaemon *notification.Daemon) int {
+	count := 0
+	for _, nt := range nDaemon.Notifications() {
+		if !nt.Transient() {
+			count++
+		}
+	}
+	return count
+}
+
+func (p *notificationPanel) updateTitle() {
+	p.title.SetText(fmt.Sprintf("%d Notifications", len(p.rows)))
+}
+
+// ListenNotifications starts the notification daemon the bar and the
+// notification panel read from.
+func ListenNotifications() (*notification.Daemon, error) {
+	return notification.Start()
+}
@@ -0,0 +1,77 @@
+package shell
+
+import (
+	"log"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/watch"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// pinnedModel holds the applications pinned to the bar and tells the
+// taskbars when the list changes. It is only used from the GTK main loop.
+type pinnedModel struct {
+	pinned    config.Pinned
+	listeners watch.Listeners[struct{}]
+}
+
+var sharedPinned *pinnedModel
+
+// getPinned returns the pinned applications of the process, loading them on
+// first use.
+func getPinned() *pinnedModel {
+	if sharedPinned != nil {
+		return sharedPinned
+	}
+
+	pinned, err := config.LoadPinned()
+	if err != nil {
+		log.Println("Error loading pinned applications:", err)
+	}
+	sharedPinned = &pinnedModel{pinned: pinned}
+	return sharedPinned
+}
+
+func (m *pinnedModel) ids() []string {
+	return m.pinned.Apps
+}
+
+func (m *pinnedModel) contains(id string) bool {
+	return m.pinned.Contains(id)
+}
+
+// toggle pins the application with the given desktop file ID, or unpins it
+// if it is pinned already, and saves the list.
+func (m *pinnedModel) toggle(id string) {
+	if m.pinned.Contains(id) {
+		m.pinned.Unpin(id)
+	} else {
+		m.pinned.Pin(id)
+	}
+
+	if err := m.pinned.Save(); err != nil {
+		log.Println("Error saving pinned applications:", err)
+	}
+	m.listeners.Notify(struct{}{})
+}
+
+// subscribe calls listener after every change. The returned function
+// unsubscribes.
+func (m *pinnedModel) subscribe(listener func()) func() {
+	return m.listeners.Add(func(struct{}) { listener() })
+}
+
+// pinMenuItem returns a menu item that pins or unpins the application with
+// the given desktop file ID.
+func pinMenuItem(id string) *gtk.MenuItem {
+	label := "Pin to bar"
+	if getPinned().contains(id) {
+		label = "Unpin from bar"
+	}
+
+	item, _ := gtk.MenuItemNewWithLabel(label)
+	item.Connect("activate", func() {
+		getPinned().toggle(id)
+	})
+	return item
+}
@@ -0,0 +1,284 @@
+package shell
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/notification"
+	"github.com/AuruTeam/desktop/sound"
+	"github.com/dlasky/gotk3-layershell/layershell"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// notificationPopups shows incoming notifications as popups stacked in a
+// corner of the screen. A popup goes away when its time is up, leaving the
+// notification in the notification panel.
+type notificationPopups struct {
+	daemon *notification.Daemon
+	cfg    config.Notifications
+	// player plays the sounds notifications ask for. It is nil when sounds
+	// are turned off or cannot be played.
+	player sound.Player
+
+	win *gtk.Window
+	box *gtk.Box
+
+	popups map[uint32]*popup
+	// queue holds the IDs of notifications waiting for room, oldest
+	// first.
+	queue []uint32
+}
+
+// popup is a shown notification.
+type popup struct {
+	widget *gtk.EventBox
+
+	// timer is running until deadline, unless the popup is hovered or
+	// stays until dismissed. remaining is the time left while it is
+	// paused.
+	timer     glib.SourceHandle
+	running   bool
+	deadline  time.Time
+	remaining time.Duration
+	hovered   bool
+}
+
+// ShowNotificationPopups shows a popup for every notification the daemon
+// receives, as configured in notifications.json. player plays the sounds
+// notifications ask for; if it is nil, the player of notifications.json or
+// one that is installed does.
+func ShowNotificationPopups(daemon *notification.Daemon, player sound.Player) {
+	cfg, err := config.LoadNotifications()
+	if err != nil {
+		log.Println("Error loading notification configuration:", err)
+	}
+
+	p := &notificationPopups{daemon: daemon, cfg: cfg, popups: make(map[uint32]*popup)}
+	switch {
+	case !cfg.Sounds:
+	case player != nil:
+		p.player = player
+	case len(cfg.SoundPlayer) > 0:
+		p.player = &sound.CommandPlayer{Command: cfg.SoundPlayer}
+	default:
+		p.player = sound.System()
+	}
+	daemon.SetSounds(p.player != nil)
+	p.createWindow()
+	daemon.Subscribe(func(e notification.Event) {
+		glib.IdleAdd(func() { p.handle(e) })
+	})
+}
+
+func (p *notificationPopups) createWindow() {
+	p.win, _ = gtk.WindowNew(gtk.WINDOW_TOPLEVEL)
+	p.win.SetTitle("Notifications")
+	p.win.SetDecorated(false)
+	p.win.SetResizable(false)
+	sc, _ := p.win.GetStyleContext()
+	sc.AddClass("notification-popups-window")
+
+	layershell.InitForWindow(p.win)
+	layershell.SetNamespace(p.win, "miracleos")
+	layershell.SetLayer(p.win, layershell.LAYER_SHELL_LAYER_OVERLAY)
+	layershell.SetKeyboardMode(p.win, layershell.LAYER_SHELL_KEYBOARD_MODE_NONE)
+
+	vertical := layershell.LAYER_SHELL_EDGE_TOP
+	if p.cfg.Corner == config.CornerBottomLeft || p.cfg.Corner == config.CornerBottomRight {
+		vertical = layershell.LAYER_SHELL_EDGE_BOTTOM
+	}
+	horizontal := layershell.LAYER_SHELL_EDGE_RIGHT
+	if p.cfg.Corner == config.CornerTopLeft || p.cfg.Corner == config.CornerBottomLeft {
+		horizontal = layershell.LAYER_SHELL_EDGE_LEFT
+	}
+	for _, edge := range []layershell.LayerShellEdgeFlags{vertical, horizontal} {
+		layershell.SetAnchor(p.win, edge, true)
+		layershell.SetMargin(p.win, edge, 10)
+	}
+
+	p.box, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+	sc, _ = p.box.GetStyleContext()
+	sc.AddClass("notification-popups")
+	p.win.Add(p.box)
+}
+
+// newestFirst tells whether new popups go on top, which keeps them next to
+// the edge of the screen.
+func (p *notificationPopups) newestFirst() bool {
+	return p.cfg.Corner == config.CornerTopLeft || p.cfg.Corner == config.CornerTopRight
+}
+
+func (p *notificationPopups) handle(e notification.Event) {
+	id := e.Notification.ID
+	switch e.Kind {
+	case notification.Added, notification.Replaced:
+		if e.Kind == notification.Added {
+			p.playSound(e.Notification)
+		}
+		if slices.Contains(p.queue, id) {
+			// It is shown as it is when its turn comes.
+			return
+		}
+		if pp, ok := p.popups[id]; ok {
+			p.fill(pp, e.Notification)
+			p.startTimer(id, pp, e.Notification)
+			return
+		}
+		if len(p.popups) >= p.cfg.MaxVisible {
+			p.queue = append(p.queue, id)
+			return
+		}
+		p.show(e.Notification)
+	case notification.Closed:
+		p.queue = slices.DeleteFunc(p.queue, func(queued uint32) bool { return queued == id })
+		p.remove(id)
+	}
+}
+
+// show adds a popup for n.
+func (p *notificationPopups) show(n notification.Notification) {
+	pp := &popup{}
+	pp.widget, _ = gtk.EventBoxNew()
+	pp.widget.AddEvents(int(gdk.ENTER_NOTIFY_MASK | gdk.LEAVE_NOTIFY_MASK))
+	sc, _ := pp.widget.GetStyleContext()
+	sc.AddClass("notification-popup")
+
+	// The time left stands still while the pointer is on the popup.
+	pp.widget.Connect("enter-notify-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+		if gdk.EventCrossingNewFromEvent(ev).Detail() != gdk.NOTIFY_INFERIOR {
+			pp.hovered = true
+			p.pauseTimer(pp)
+		}
+		return false
+	})
+	pp.widget.Connect("leave-notify-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+		if gdk.EventCrossingNewFromEvent(ev).Detail() != gdk.NOTIFY_INFERIOR {
+			pp.hovered = false
+			p.resumeTimer(n.ID, pp)
+		}
+		return false
+	})
+
+	p.fill(pp, n)
+	p.box.PackStart(pp.widget, false, false, 0)
+	if p.newestFirst() {
+		p.box.ReorderChild(pp.widget, 0)
+	}
+	p.popups[n.ID] = pp
+	p.startTimer(n.ID, pp, n)
+
+	p.win.ShowAll()
+}
+
+// fill shows n in the popup, replacing what it showed before.
+func (p *notificationPopups) fill(pp *popup, n notification.Notification) {
+	if child, err := pp.widget.GetChild(); err == nil && child != nil {
+		child.ToWidget().Destroy()
+	}
+	pp.widget.Add(createNotification(&n, p.daemon))
+	pp.widget.ShowAll()
+}
+
+// timeout returns how long the popup of n is shown, or 0 if it stays until
+// dismissed. Critical notifications stay as long as configured, whatever
+// the application asks for.
+func (p *notificationPopups) timeout(n notification.Notification) time.Duration {
+	switch {
+	case n.Urgency() == notification.UrgencyCritical:
+		return time.Duration(p.cfg.CriticalTimeout) * time.Second
+	case n.ExpireTimeout >= 0:
+		return n.ExpireTimeout
+	case n.Urgency() == notification.UrgencyLow:
+		return time.Duration(p.cfg.LowTimeout) * time.Second
+	}
+	return time.Duration(p.cfg.Timeout) * time.Second
+}
+
+func (p *notificationPopups) startTimer(id uint32, pp *popup, n notification.Notification) {
+	p.stopTimer(pp)
+	pp.remaining = p.timeout(n)
+	p.resumeTimer(id, pp)
+}
+
+func (p *notificationPopups) stopTimer(pp *popup) {
+	if pp.running {
+		glib.SourceRemove(pp.timer)
+		pp.running = false
+	}
+}
+
+func (p *notificationPopups) pauseTimer(pp *popup) {
+	if pp.running {
+		p.stopTimer(pp)
+		pp.remaining = max(time.Until(pp.deadline), time.Millisecond)
+	}
+}
+
+func (p *notificationPopups) resumeTimer(id uint32, pp *popup) {
+	if pp.running || pp.hovered || pp.remaining <= 0 {
+		return
+	}
+	pp.deadline = time.Now().Add(pp.remaining)
+	pp.timer = glib.TimeoutAdd(uint(pp.remaining.Milliseconds()), func() bool {
+		pp.running = false
+		p.expire(id)
+		return false
+	})
+	pp.running = true
+}
+
+// expire takes the popup of a notification away once its time is up.
+// Transient notifications are not kept in the notification panel, so they
+// are closed.
+func (p *notificationPopups) expire(id uint32) {
+	if n, ok := p.daemon.Get(id); ok && n.Transient() {
+		p.daemon.Close(id, notification.ReasonExpired)
+	}
+	p.remove(id)
+}
+
+// remove takes the popup of a notification away, if it has one, and shows
+// the next waiting notification in its place.
+func (p *notificationPopups) remove(id uint32) {
+	pp, ok := p.popups[id]
+	if !ok {
+		return
+	}
+	p.stopTimer(pp)
+	pp.widget.Destroy()
+	delete(p.popups, id)
+
+	for len(p.popups) < p.cfg.MaxVisible && len(p.queue) > 0 {
+		next := p.queue[0]
+		p.queue = p.queue[1:]
+		if n, ok := p.daemon.Get(next); ok {
+			p.show(n)
+		}
+	}
+
+	if len(p.popups) == 0 {
+		p.win.Hide()
+	}
+	// Let the window shrink to the popups that are left.
+	p.win.Resize(1, 1)
+}
+
+// playSound plays the sound a new notification asks for.
+func (p *notificationPopups) playSound(n notification.Notification) {
+	if p.player == nil || n.SuppressSound() {
+		return
+	}
+	var err error
+	if file := n.SoundFile(); file != "" {
+		err = p.player.PlayFile(file)
+	} else if name := n.SoundName(); name != "" {
+		err = p.player.PlayName(name)
+	}
+	if err != nil {
+		log.Println("Failed to play notification sound:", err)
+	}
+}
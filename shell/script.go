@@ -0,0 +1,273 @@
+package shell
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// scriptOptions are the options of a script module in the bar layout.
+type scriptOptions struct {
+	// Exec is the command line, run with sh -c.
+	Exec string `json:"exec"`
+	// Interval is how often the command is run, in seconds. A continuous
+	// command is restarted after this long once it exits. Zero runs the
+	// command only once.
+	Interval int `json:"interval"`
+	// Continuous commands keep running, and every line they print
+	// replaces the content of the module.
+	Continuous bool `json:"continuous"`
+	// Format is "text" or "json".
+	Format string `json:"format"`
+	// Class is added to the style classes of the module.
+	Class string `json:"class"`
+
+	OnClick       string `json:"onClick"`
+	OnMiddleClick string `json:"onMiddleClick"`
+	OnRightClick  string `json:"onRightClick"`
+	OnScrollUp    string `json:"onScrollUp"`
+	OnScrollDown  string `json:"onScrollDown"`
+}
+
+// scriptOutput is what a script shows on the bar.
+type scriptOutput struct {
+	Text    string      `json:"text"`
+	Tooltip string      `json:"tooltip"`
+	Class   scriptClass `json:"class"`
+	Icon    string      `json:"icon"`
+}
+
+// scriptClass is a style class or a list of them.
+type scriptClass []string
+
+func (c *scriptClass) UnmarshalJSON(data []byte) error {
+	var class string
+	if err := json.Unmarshal(data, &class); err == nil {
+		*c = strings.Fields(class)
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(c))
+}
+
+// script runs the command of a script module and keeps its latest output.
+// It is the update source of the module.
+type script struct {
+	opts scriptOptions
+
+	mu     sync.Mutex
+	output scriptOutput
+
+	// refresh makes an interval script run again right away.
+	refresh chan struct{}
+}
+
+// newScriptModule creates a module that shows the output of a command, in
+// the manner of i3blocks and waybar custom modules. Plain text output is
+// the text on the first line and an optional tooltip on the second; JSON
+// output is an object with text, tooltip, class and icon.
+func newScriptModule(ctx ModuleContext, entry config.Module) (*Module, error) {
+	opts := scriptOptions{Format: "text"}
+	if err := entry.Decode(&opts); err != nil {
+		return nil, err
+	}
+	if opts.Exec == "" {
+		return nil, errors.New("exec is required")
+	}
+	if opts.Format != "text" && opts.Format != "json" {
+		return nil, fmt.Errorf("unknown format %q", opts.Format)
+	}
+
+	s := &script{opts: opts, refresh: make(chan struct{}, 1)}
+
+	eventBox, _ := gtk.EventBoxNew()
+	eventBox.AddEvents(int(gdk.SCROLL_MASK))
+	// The module stays hidden while the script has nothing to show.
+	eventBox.SetNoShowAll(true)
+	sc, _ := eventBox.GetStyleContext()
+	sc.AddClass("script")
+	if opts.Class != "" {
+		sc.AddClass(opts.Class)
+	}
+
+	box, _ := gtk.BoxNew(ctx.Orientation(), 5)
+	image, _ := gtk.ImageNew()
+	label, _ := gtk.LabelNew("")
+	box.PackStart(image, false, false, 0)
+	box.PackStart(label, false, false, 0)
+	eventBox.Add(box)
+
+	eventBox.Connect("button-press-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+		switch gdk.EventButtonNewFromEvent(ev).Button() {
+		case gdk.BUTTON_PRIMARY:
+			return s.runAction(opts.OnClick)
+		case gdk.BUTTON_MIDDLE:
+			return s.runAction(opts.OnMiddleClick)
+		case gdk.BUTTON_SECONDARY:
+			return s.runAction(opts.OnRightClick)
+		}
+		return false
+	})
+	eventBox.Connect("scroll-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+		switch gdk.EventScrollNewFromEvent(ev).Direction() {
+		case gdk.SCROLL_UP:
+			return s.runAction(opts.OnScrollUp)
+		case gdk.SCROLL_DOWN:
+			return s.runAction(opts.OnScrollDown)
+		}
+		return false
+	})
+
+	var classes []string
+	update := func() {
+		out := s.latest()
+
+		label.SetText(out.Text)
+		eventBox.SetTooltipText(out.Tooltip)
+
+		image.Clear()
+		if strings.HasPrefix(out.Icon, "/") {
+			image.SetFromFile(out.Icon)
+		} else if out.Icon != "" {
+			image.SetFromIconName(out.Icon, gtk.ICON_SIZE_BUTTON)
+		}
+
+		for _, class := range classes {
+			sc.RemoveClass(class)
+		}
+		classes = out.Class
+		for _, class := range classes {
+			sc.AddClass(class)
+		}
+
+		if out.Text == "" && out.Icon == "" {
+			eventBox.Hide()
+		} else {
+			eventBox.ShowAll()
+		}
+	}
+
+	return &Module{Widget: eventBox, Sources: []ModuleSource{{Status: s, Update: update}}}, nil
+}
+
+func (s *script) latest() scriptOutput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.output
+}
+
+// Watch runs the script until the returned function is called.
+func (s *script) Watch(changed func()) (func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.run(ctx, changed)
+	return cancel, nil
+}
+
+func (s *script) run(ctx context.Context, changed func()) {
+	interval := time.Duration(s.opts.Interval) * time.Second
+	for {
+		var err error
+		if s.opts.Continuous {
+			err = s.runContinuous(ctx, changed)
+		} else {
+			err = s.runOnce(ctx, changed)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("Script %q failed: %v", s.opts.Exec, err)
+		}
+		if interval == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		case <-s.refresh:
+		}
+	}
+}
+
+func (s *script) runOnce(ctx context.Context, changed func()) error {
+	out, err := exec.CommandContext(ctx, "sh", "-c", s.opts.Exec).Output()
+	if err != nil {
+		return err
+	}
+	return s.set(out, changed)
+}
+
+func (s *script) runContinuous(ctx context.Context, changed func()) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.opts.Exec)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if err := s.set(scanner.Bytes(), changed); err != nil {
+			log.Printf("Script %q: %v", s.opts.Exec, err)
+		}
+	}
+	return cmd.Wait()
+}
+
+// set parses the output of the script and reports it.
+func (s *script) set(data []byte, changed func()) error {
+	var out scriptOutput
+	if s.opts.Format == "json" {
+		if err := json.Unmarshal(bytes.TrimSpace(data), &out); err != nil {
+			return fmt.Errorf("invalid output: %w", err)
+		}
+	} else {
+		lines := strings.SplitN(strings.TrimRight(string(data), "\n"), "\n", 3)
+		out.Text = lines[0]
+		if len(lines) > 1 {
+			out.Tooltip = lines[1]
+		}
+	}
+
+	s.mu.Lock()
+	s.output = out
+	s.mu.Unlock()
+	changed()
+	return nil
+}
+
+// runAction runs the command bound to a click or scroll, if any, and then
+// runs an interval script again so it shows the effect right away.
+func (s *script) runAction(command string) bool {
+	if command == "" {
+		return false
+	}
+	cmd := exec.Command("sh", "-c", command)
+	if err := cmd.Start(); err != nil {
+		log.Printf("Error running %q: %v", command, err)
+		return true
+	}
+	go func() {
+		cmd.Wait()
+		select {
+		case s.refresh <- struct{}{}:
+		default:
+		}
+	}()
+	return true
+}
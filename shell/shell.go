@@ -0,0 +1,126 @@
+// Package shell contains the widgets of the Auru desktop shell: the main bar,
+// the main menu and the notification panel. The binaries under cmd/ are thin
+// wrappers around it, and session managers can embed the same pieces.
+package shell
+
+import (
+	"log"
+
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
+)
+
+// DefaultCSSPath is where the installed stylesheet of the shell lives.
+const DefaultCSSPath = "/opt/AuruTeam/desktop/desktop.css"
+
+// LoadCSS loads the stylesheet at path into GTK for the default screen.
+func LoadCSS(path string) error {
+	provider, err := gtk.CssProviderNew()
+	if err != nil {
+		return err
+	}
+
+	if err := provider.LoadFromPath(path); err != nil {
+		return err
+	}
+
+	return addProvider(provider)
+}
+
+// LoadCSSFromData loads a stylesheet given as a string into GTK for the default screen.
+func LoadCSSFromData(css string) error {
+	provider, err := gtk.CssProviderNew()
+	if err != nil {
+		return err
+	}
+
+	if err := provider.LoadFromData(css); err != nil {
+		return err
+	}
+
+	return addProvider(provider)
+}
+
+func addProvider(provider *gtk.CssProvider) error {
+	display, err := gdk.DisplayGetDefault()
+	if err != nil {
+		return err
+	}
+
+	screen, err := display.GetDefaultScreen()
+	if err != nil {
+		return err
+	}
+
+	gtk.AddProviderForScreen(screen, provider, gtk.STYLE_PROVIDER_PRIORITY_APPLICATION)
+	return nil
+}
+
+// scalePixbuf scales a Pixbuf while maintaining aspect ratio
+func scalePixbuf(pixbuf *gdk.Pixbuf, maxWidth, maxHeight int) *gdk.Pixbuf {
+	width := pixbuf.GetWidth()
+	height := pixbuf.GetHeight()
+
+	// Maintain aspect ratio
+	scaleFactor := float64(maxWidth) / float64(width)
+	if float64(maxHeight)/float64(height) < scaleFactor {
+		scaleFactor = float64(maxHeight) / float64(height)
+	}
+
+	newWidth := int(float64(width) * scaleFactor)
+	newHeight := int(float64(height) * scaleFactor)
+
+	scaledPixbuf, err := pixbuf.ScaleSimple(newWidth, newHeight, gdk.INTERP_BILINEAR)
+	if err != nil {
+		log.Fatal("Could not scale image:", err)
+	}
+	return scaledPixbuf
+}
+
+// pixbufFromData makes a Pixbuf of 8 bit RGB or RGBA pixels. gotk3's
+// PixbufNewFromBytes hands the slice to GdkPixbuf as if it were a GBytes,
+// so the pixels are copied row by row into a new Pixbuf instead.
+func pixbufFromData(data []byte, hasAlpha bool, width, height, rowStride int) (*gdk.Pixbuf, error) {
+	pixbuf, err := gdk.PixbufNew(gdk.COLORSPACE_RGB, hasAlpha, 8, width, height)
+	if err != nil {
+		return nil, err
+	}
+	channels := 3
+	if hasAlpha {
+		channels = 4
+	}
+	pixels := pixbuf.GetPixels()
+	stride := pixbuf.GetRowstride()
+	for y := range height {
+		copy(pixels[y*stride:y*stride+width*channels], data[y*rowStride:])
+	}
+	return pixbuf, nil
+}
+
+func firstN(s string, n int) string {
+	i := 0
+	for j := range s {
+		if i == n {
+			return s[:j]
+		}
+		i++
+	}
+	return s
+}
+
+// ellipsizedLabel returns a left-aligned label that shortens long text.
+func ellipsizedLabel(text string) *gtk.Label {
+	label, _ := gtk.LabelNew(text)
+	label.SetXAlign(0)
+	label.SetMaxWidthChars(30)
+	label.SetEllipsize(pango.ELLIPSIZE_END)
+	return label
+}
+
+// clearContainer destroys the children of c.
+func clearContainer(c *gtk.Container) {
+	c.GetChildren().Foreach(func(item interface{}) {
+		item.(*gtk.Widget).Destroy()
+	})
+}
@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"log"
+	"sync/atomic"
+
+	"github.com/AuruTeam/desktop/status"
+	"github.com/gotk3/gotk3/glib"
+)
+
+// statusPollInterval is how often a status icon is refreshed when its event
+// source is not available.
+const statusPollInterval = 500
+
+// watchStatus calls update once and then every time src reports a change, on
+// the GTK main loop. Bursts of events are coalesced into a single update. If
+// src cannot be watched, update is polled instead. The returned function
+// stops watching.
+func watchStatus(name string, src status.Source, update func()) func() {
+	update()
+
+	var pending atomic.Bool
+	stop, err := src.Watch(func() {
+		if pending.CompareAndSwap(false, true) {
+			glib.IdleAdd(func() {
+				pending.Store(false)
+				update()
+			})
+		}
+	})
+	if err == nil {
+		return stop
+	}
+
+	log.Printf("Cannot watch %s status, polling instead: %v", name, err)
+	handle := glib.TimeoutAdd(uint(statusPollInterval), func() bool {
+		update()
+
+		// Return true to keep the timeout active.
+		return true
+	})
+	return func() { glib.SourceRemove(handle) }
+}
@@ -0,0 +1,465 @@
+package shell
+
+import (
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AuruTeam/desktop/apps"
+	"github.com/AuruTeam/desktop/compositor"
+	"github.com/AuruTeam/desktop/toplevel"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
+)
+
+// taskbar shows one button per application, grouping all windows that share
+// an app_id, and updates the buttons in place as windows change. Pinned
+// applications come first and keep their button while not running.
+type taskbar struct {
+	box     *gtk.Box
+	groups  map[string]*taskGroup
+	windows map[*toplevel.Handle]*taskGroup
+
+	// mon limits the taskbar to the windows on one monitor if not nil.
+	mon    *gdk.Monitor
+	layout barLayout
+}
+
+// taskGroup is the taskbar button of one application and its windows. It is
+// keyed by appKey, so a pinned launcher and the windows of the same
+// application share it.
+type taskGroup struct {
+	tb      *taskbar
+	key     string
+	pinned  bool
+	button  *gtk.Button
+	image   *gtk.Image
+	badge   *gtk.Label
+	popover *gtk.Popover
+
+	// windows are kept in the order they were opened.
+	windows []toplevel.Toplevel
+}
+
+// createWorkspaces creates the taskbar. If mon is not nil, it only shows the
+// windows on that monitor.
+func createWorkspaces(mon *gdk.Monitor, layout barLayout) *gtk.Box {
+	box, _ := gtk.BoxNew(layout.orientation, 10)
+	layout.align(box, gtk.ALIGN_START)
+	sc, _ := box.GetStyleContext()
+	sc.AddClass("workspaces")
+
+	tb := &taskbar{
+		box:     box,
+		groups:  make(map[string]*taskGroup),
+		windows: make(map[*toplevel.Handle]*taskGroup),
+		mon:     mon,
+		layout:  layout,
+	}
+	tb.syncPinned()
+	unsubscribePinned := getPinned().subscribe(tb.syncPinned)
+	unsubscribe := getToplevels().subscribe(tb.handle)
+	box.Connect("destroy", func() {
+		unsubscribePinned()
+		unsubscribe()
+	})
+
+	return box
+}
+
+func (tb *taskbar) handle(e toplevel.Event) {
+	t := e.Toplevel
+	switch e.Kind {
+	case toplevel.Added:
+		if tb.shows(t) {
+			tb.add(t)
+		}
+	case toplevel.Changed:
+		g, ok := tb.windows[t.Handle]
+		switch {
+		case !ok && tb.shows(t):
+			// The window moved to the monitor of the taskbar.
+			tb.add(t)
+			return
+		case !ok:
+			return
+		case !tb.shows(t):
+			tb.remove(t)
+			return
+		}
+		if g.key != appKey(t.AppID) {
+			// The window moves to the group of its new application.
+			tb.remove(t)
+			tb.add(t)
+			return
+		}
+		g.updateWindow(t)
+	case toplevel.Closed:
+		tb.remove(t)
+	}
+}
+
+// shows reports whether the taskbar shows the window t.
+func (tb *taskbar) shows(t toplevel.Toplevel) bool {
+	return tb.mon == nil || onMonitor(t, tb.mon)
+}
+
+func (tb *taskbar) add(t toplevel.Toplevel) {
+	key := appKey(t.AppID)
+	g, ok := tb.groups[key]
+	if !ok {
+		g = tb.newGroup(key)
+	}
+	tb.windows[t.Handle] = g
+	g.windows = append(g.windows, t)
+	g.refresh()
+}
+
+func (tb *taskbar) remove(t toplevel.Toplevel) {
+	g, ok := tb.windows[t.Handle]
+	if !ok {
+		return
+	}
+	delete(tb.windows, t.Handle)
+
+	for i, w := range g.windows {
+		if w.Handle == t.Handle {
+			g.windows = append(g.windows[:i], g.windows[i+1:]...)
+			break
+		}
+	}
+	if len(g.windows) == 0 && !g.pinned {
+		g.destroy()
+		return
+	}
+	g.refresh()
+}
+
+// syncPinned creates the buttons of newly pinned applications, moves all
+// pinned buttons to the front in the configured order and drops launchers
+// that are no longer pinned nor running.
+func (tb *taskbar) syncPinned() {
+	pinned := getPinned()
+	for i, id := range pinned.ids() {
+		g, ok := tb.groups[id]
+		if !ok {
+			g = tb.newGroup(id)
+		}
+		g.pinned = true
+		tb.box.ReorderChild(g.button, i)
+		g.refresh()
+	}
+
+	for _, g := range tb.groups {
+		if g.pinned && !pinned.contains(g.key) {
+			g.pinned = false
+			if len(g.windows) == 0 {
+				g.destroy()
+			} else {
+				g.refresh()
+			}
+		}
+	}
+}
+
+func (tb *taskbar) newGroup(key string) *taskGroup {
+	g := &taskGroup{tb: tb, key: key}
+	tb.groups[key] = g
+	g.button, _ = gtk.ButtonNew()
+	sc, _ := g.button.GetStyleContext()
+	sc.AddClass("app")
+
+	g.image, _ = gtk.ImageNew()
+	setAppIcon(g.image, key, 16)
+
+	g.badge, _ = gtk.LabelNew("")
+	g.badge.SetHAlign(gtk.ALIGN_END)
+	g.badge.SetVAlign(gtk.ALIGN_END)
+	g.badge.SetNoShowAll(true)
+	sc, _ = g.badge.GetStyleContext()
+	sc.AddClass("app-count")
+
+	overlay, _ := gtk.OverlayNew()
+	overlay.Add(g.image)
+	overlay.AddOverlay(g.badge)
+	g.button.Add(overlay)
+
+	g.popover, _ = gtk.PopoverNew(g.button)
+	g.popover.SetPosition(g.tb.layout.popover)
+	sc, _ = g.popover.GetStyleContext()
+	sc.AddClass("app-windows")
+
+	g.button.Connect("clicked", g.clicked)
+	g.button.Connect("button-press-event", func(_ *gtk.Button, ev *gdk.Event) bool {
+		switch gdk.EventButtonNewFromEvent(ev).Button() {
+		case gdk.BUTTON_SECONDARY:
+			g.showContextMenu(ev)
+			return true
+		case gdk.BUTTON_MIDDLE:
+			// Middle-click opens another instance of the application.
+			launchApp(g.key)
+			return true
+		}
+		return false
+	})
+
+	tb.box.PackStart(g.button, false, false, 0)
+	g.button.ShowAll()
+	return g
+}
+
+func (g *taskGroup) destroy() {
+	g.button.Destroy()
+	delete(g.tb.groups, g.key)
+}
+
+func (g *taskGroup) clicked() {
+	if len(g.windows) == 0 {
+		launchApp(g.key)
+		return
+	}
+	if len(g.windows) > 1 {
+		g.fillWindowList()
+		g.popover.ShowAll()
+		g.popover.Popup()
+		return
+	}
+
+	// Like every other taskbar, clicking the focused window hides it.
+	w := g.windows[0]
+	switch {
+	case w.State.Activated:
+		w.Handle.SetMinimized(true)
+	case w.State.Minimized:
+		w.Handle.SetMinimized(false)
+		w.Handle.Activate()
+	default:
+		w.Handle.Activate()
+	}
+}
+
+// updateWindow applies a new state of one of the windows of the group.
+func (g *taskGroup) updateWindow(t toplevel.Toplevel) {
+	for i, w := range g.windows {
+		if w.Handle == t.Handle {
+			g.windows[i] = t
+		}
+	}
+	g.refresh()
+}
+
+// refresh updates the badge, the tooltip, the state classes and, if it is
+// open, the window list of the group.
+func (g *taskGroup) refresh() {
+	g.badge.SetText(strconv.Itoa(len(g.windows)))
+	g.badge.SetVisible(len(g.windows) > 1)
+
+	titles := make([]string, 0, len(g.windows))
+	for _, w := range g.windows {
+		titles = append(titles, g.windowTitle(w))
+	}
+	if len(titles) == 0 {
+		titles = append(titles, g.appName())
+	}
+	g.button.SetTooltipText(strings.Join(titles, "\n"))
+
+	// The group is as active as its most active window.
+	var state toplevel.State
+	state.Minimized = len(g.windows) > 0
+	for _, w := range g.windows {
+		state.Activated = state.Activated || w.State.Activated
+		state.Maximized = state.Maximized || w.State.Maximized
+		state.Fullscreen = state.Fullscreen || w.State.Fullscreen
+		state.Minimized = state.Minimized && w.State.Minimized
+	}
+
+	sc, _ := g.button.GetStyleContext()
+	for class, on := range map[string]bool{
+		"pinned":     g.pinned,
+		"running":    len(g.windows) > 0,
+		"activated":  state.Activated,
+		"minimized":  state.Minimized,
+		"maximized":  state.Maximized,
+		"fullscreen": state.Fullscreen,
+	} {
+		if on {
+			sc.AddClass(class)
+		} else {
+			sc.RemoveClass(class)
+		}
+	}
+
+	if g.popover.IsVisible() {
+		if len(g.windows) > 1 {
+			g.fillWindowList()
+			g.popover.ShowAll()
+		} else {
+			g.popover.Popdown()
+		}
+	}
+}
+
+// fillWindowList replaces the content of the popover with one row per
+// window, offering to focus, minimize or close it.
+func (g *taskGroup) fillWindowList() {
+	if child, err := g.popover.GetChild(); err == nil && child != nil {
+		child.ToWidget().Destroy()
+	}
+
+	list, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+	for _, w := range g.windows {
+		row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+		sc, _ := row.GetStyleContext()
+		sc.AddClass("app-window")
+		if w.State.Activated {
+			sc.AddClass("activated")
+		}
+
+		titleLabel, _ := gtk.LabelNew(g.windowTitle(w))
+		titleLabel.SetXAlign(0)
+		titleLabel.SetMaxWidthChars(40)
+		titleLabel.SetEllipsize(pango.ELLIPSIZE_END)
+		titleButton, _ := gtk.ButtonNew()
+		titleButton.SetRelief(gtk.RELIEF_NONE)
+		titleButton.Add(titleLabel)
+		titleButton.Connect("clicked", func() {
+			g.popover.Popdown()
+			w.Handle.Activate()
+		})
+
+		minimizeButton, _ := gtk.ButtonNewFromIconName("window-minimize-symbolic", gtk.ICON_SIZE_BUTTON)
+		minimizeButton.SetRelief(gtk.RELIEF_NONE)
+		minimizeButton.Connect("clicked", func() {
+			w.Handle.SetMinimized(!w.State.Minimized)
+		})
+
+		closeButton, _ := gtk.ButtonNewFromIconName("window-close-symbolic", gtk.ICON_SIZE_BUTTON)
+		closeButton.SetRelief(gtk.RELIEF_NONE)
+		closeButton.Connect("clicked", func() {
+			w.Handle.Close()
+		})
+
+		row.PackStart(titleButton, true, true, 0)
+		row.PackEnd(closeButton, false, false, 0)
+		row.PackEnd(minimizeButton, false, false, 0)
+		list.PackStart(row, false, false, 0)
+	}
+	g.popover.Add(list)
+}
+
+// showContextMenu pops up the right-click menu of the group: the window
+// actions, the desktop actions of the application and pinning.
+func (g *taskGroup) showContextMenu(ev *gdk.Event) {
+	var items []gtk.IMenuItem
+
+	if len(g.windows) == 1 {
+		items = append(items, windowMenuItems(g.windows[0])...)
+	} else {
+		for _, w := range g.windows {
+			submenu, _ := gtk.MenuNew()
+			for _, item := range windowMenuItems(w) {
+				submenu.Append(item)
+			}
+			item, _ := gtk.MenuItemNewWithLabel(g.windowTitle(w))
+			item.SetSubmenu(submenu)
+			items = append(items, item)
+		}
+	}
+
+	if entry, ok := apps.Lookup(g.key); ok {
+		if len(items) > 0 {
+			items = append(items, menuSeparator())
+		}
+		for _, action := range entry.Actions {
+			item, _ := gtk.MenuItemNewWithLabel(action.Name)
+			item.Connect("activate", func() {
+				if err := entry.Launch(action); err != nil {
+					log.Println("Error launching", action.Name+":", err)
+				}
+			})
+			items = append(items, item)
+		}
+		if len(entry.Actions) > 0 {
+			items = append(items, menuSeparator())
+		}
+		items = append(items, pinMenuItem(g.key))
+	}
+
+	if len(items) > 0 {
+		popupMenu(ev, items...)
+	}
+}
+
+// windowMenuItems returns the menu items that act on a single window.
+func windowMenuItems(w toplevel.Toplevel) []gtk.IMenuItem {
+	h := w.Handle
+	var items []gtk.IMenuItem
+	add := func(label string, activate func()) {
+		item, _ := gtk.MenuItemNewWithLabel(label)
+		item.Connect("activate", activate)
+		items = append(items, item)
+	}
+
+	if w.State.Minimized {
+		add("Unminimize", func() { h.SetMinimized(false) })
+	} else {
+		add("Minimize", func() { h.SetMinimized(true) })
+	}
+	if w.State.Maximized {
+		add("Restore", func() { h.SetMaximized(false) })
+	} else {
+		add("Maximize", func() { h.SetMaximized(true) })
+	}
+
+	if h.SupportsFullscreen() {
+		if w.State.Fullscreen {
+			add("Leave Fullscreen", func() { h.SetFullscreen(false) })
+		} else {
+			add("Fullscreen", func() { h.SetFullscreen(true) })
+		}
+	}
+
+	// Windows are moved through the IPC of the compositor, which knows the
+	// outputs by name.
+	outputs := slices.DeleteFunc(getToplevels().outputs(), func(o toplevel.Output) bool { return o.Name == "" })
+	if len(outputs) > 1 {
+		if c, err := compositor.Detect(); err == nil {
+			submenu, _ := gtk.MenuNew()
+			for _, o := range outputs {
+				label := o.Name
+				if o.Description != "" {
+					label = o.Description
+				}
+				item, _ := gtk.MenuItemNewWithLabel(label)
+				item.Connect("activate", func() { getToplevels().moveToOutput(c, w, o) })
+				submenu.Append(item)
+			}
+			item, _ := gtk.MenuItemNewWithLabel("Move to Output")
+			item.SetSubmenu(submenu)
+			items = append(items, item)
+		}
+	}
+
+	items = append(items, menuSeparator())
+	add("Close", h.Close)
+	return items
+}
+
+// windowTitle returns the title of a window of the group, falling back to
+// the name of the application for untitled windows.
+func (g *taskGroup) windowTitle(w toplevel.Toplevel) string {
+	if w.Title != "" {
+		return w.Title
+	}
+	return g.appName()
+}
+
+// appName returns the display name of the application of the group.
+func (g *taskGroup) appName() string {
+	if entry, ok := apps.Lookup(g.key); ok && entry.Name != "" {
+		return entry.Name
+	}
+	return g.key
+}
@@ -0,0 +1,145 @@
+package shell
+
+import (
+	"log"
+	"sync"
+
+	"github.com/AuruTeam/desktop/compositor"
+	"github.com/AuruTeam/desktop/toplevel"
+	"github.com/AuruTeam/desktop/watch"
+	"github.com/gotk3/gotk3/glib"
+)
+
+// toplevelModel relays toplevel events to the GTK main loop and keeps the
+// current list of windows, so that several widgets can share one compositor
+// connection.
+type toplevelModel struct {
+	mu    sync.Mutex
+	queue []toplevel.Event
+
+	manager *toplevel.Manager
+
+	// The fields below are only accessed from the GTK main loop.
+	toplevels map[*toplevel.Handle]toplevel.Toplevel
+	order     []*toplevel.Handle
+	listeners watch.Listeners[toplevel.Event]
+}
+
+var sharedToplevels *toplevelModel
+
+// getToplevels returns the toplevel model of the process, connecting to the
+// compositor on first use. It must be called from the GTK main loop.
+func getToplevels() *toplevelModel {
+	if sharedToplevels != nil {
+		return sharedToplevels
+	}
+
+	sharedToplevels = &toplevelModel{
+		toplevels: make(map[*toplevel.Handle]toplevel.Toplevel),
+	}
+	manager, err := toplevel.Connect(sharedToplevels.push)
+	if err != nil {
+		log.Println("Error tracking toplevels:", err)
+	}
+	sharedToplevels.manager = manager
+	return sharedToplevels
+}
+
+// push queues an event from the toplevel manager goroutine and schedules a
+// flush on the main loop if none is pending.
+func (m *toplevelModel) push(e toplevel.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queue) == 0 {
+		glib.IdleAdd(m.flush)
+	}
+	m.queue = append(m.queue, e)
+}
+
+func (m *toplevelModel) flush() {
+	m.mu.Lock()
+	queue := m.queue
+	m.queue = nil
+	m.mu.Unlock()
+
+	for _, e := range queue {
+		h := e.Toplevel.Handle
+		switch e.Kind {
+		case toplevel.Added:
+			m.order = append(m.order, h)
+			m.toplevels[h] = e.Toplevel
+		case toplevel.Changed:
+			m.toplevels[h] = e.Toplevel
+		case toplevel.Closed:
+			delete(m.toplevels, h)
+			for i, o := range m.order {
+				if o == h {
+					m.order = append(m.order[:i], m.order[i+1:]...)
+					break
+				}
+			}
+		}
+
+		m.listeners.Notify(e)
+	}
+}
+
+// subscribe calls listener for every toplevel event, starting with an Added
+// event for each existing toplevel. The returned function unsubscribes.
+func (m *toplevelModel) subscribe(listener func(toplevel.Event)) func() {
+	for _, h := range m.order {
+		listener(toplevel.Event{Kind: toplevel.Added, Toplevel: m.toplevels[h]})
+	}
+
+	return m.listeners.Add(listener)
+}
+
+// outputs returns the outputs of the compositor.
+func (m *toplevelModel) outputs() []toplevel.Output {
+	if m.manager == nil {
+		return nil
+	}
+	return m.manager.Outputs()
+}
+
+// activateTimeout is how long moveToOutput waits for a window to get
+// activated, in milliseconds.
+const activateTimeout = 1000
+
+// moveToOutput moves the window w to the output o through the IPC of the
+// compositor c. The IPC only moves the focused window, so w is activated
+// first and moved once the compositor reports it activated.
+func (m *toplevelModel) moveToOutput(c compositor.Compositor, w toplevel.Toplevel, o toplevel.Output) {
+	move := func() {
+		go func() {
+			if err := c.MoveToOutput(o.Name); err != nil {
+				log.Println("Error moving window to", o.Name+":", err)
+			}
+		}()
+	}
+	if current, ok := m.toplevels[w.Handle]; ok && current.State.Activated {
+		move()
+		return
+	}
+
+	var unsubscribe func()
+	timer := glib.TimeoutAdd(activateTimeout, func() bool {
+		unsubscribe()
+		return false
+	})
+	unsubscribe = m.subscribe(func(e toplevel.Event) {
+		if e.Toplevel.Handle != w.Handle {
+			return
+		}
+		if e.Kind != toplevel.Closed {
+			if !e.Toplevel.State.Activated {
+				return
+			}
+			move()
+		}
+		unsubscribe()
+		glib.SourceRemove(timer)
+	})
+	w.Handle.Activate()
+}
@@ -0,0 +1,346 @@
+package shell
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AuruTeam/desktop/config"
+	"github.com/AuruTeam/desktop/tray"
+	"github.com/AuruTeam/desktop/watch"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// trayIconSize is the size of tray icons in pixels.
+const trayIconSize = 16
+
+// trayModel relays tray events to the GTK main loop and keeps the current
+// list of items, so that the trays of several bars share one host.
+type trayModel struct {
+	mu    sync.Mutex
+	queue []tray.Event
+
+	// The fields below are only accessed from the GTK main loop.
+	items      map[*tray.Handle]tray.Item
+	order      []*tray.Handle
+	listeners  watch.Listeners[tray.Event]
+	themePaths map[string]bool
+}
+
+var sharedTray *trayModel
+
+// getTray returns the tray model of the process, starting the tray host on
+// first use. It must be called from the GTK main loop.
+func getTray() *trayModel {
+	if sharedTray != nil {
+		return sharedTray
+	}
+
+	sharedTray = &trayModel{
+		items:      make(map[*tray.Handle]tray.Item),
+		themePaths: make(map[string]bool),
+	}
+	if _, err := tray.Connect(sharedTray.push); err != nil {
+		log.Println("Error starting the tray host:", err)
+	}
+	return sharedTray
+}
+
+// push queues an event from the tray host and schedules a flush on the main
+// loop if none is pending.
+func (m *trayModel) push(e tray.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queue) == 0 {
+		glib.IdleAdd(m.flush)
+	}
+	m.queue = append(m.queue, e)
+}
+
+func (m *trayModel) flush() {
+	m.mu.Lock()
+	queue := m.queue
+	m.queue = nil
+	m.mu.Unlock()
+
+	for _, e := range queue {
+		h := e.Item.Handle
+		switch e.Kind {
+		case tray.Added:
+			m.order = append(m.order, h)
+			m.items[h] = e.Item
+		case tray.Changed:
+			m.items[h] = e.Item
+		case tray.Removed:
+			delete(m.items, h)
+			for i, o := range m.order {
+				if o == h {
+					m.order = append(m.order[:i], m.order[i+1:]...)
+					break
+				}
+			}
+		}
+
+		m.listeners.Notify(e)
+	}
+}
+
+// subscribe calls listener for every tray event, starting with an Added
+// event for each existing item. The returned function unsubscribes.
+func (m *trayModel) subscribe(listener func(tray.Event)) func() {
+	for _, h := range m.order {
+		listener(tray.Event{Kind: tray.Added, Item: m.items[h]})
+	}
+
+	return m.listeners.Add(listener)
+}
+
+// addThemePath lets the icon theme find the icons an item ships in its own
+// directory.
+func (m *trayModel) addThemePath(path string) {
+	if path == "" || m.themePaths[path] {
+		return
+	}
+	m.themePaths[path] = true
+	if theme, err := gtk.IconThemeGetDefault(); err == nil {
+		theme.AppendSearchPath(path)
+	}
+}
+
+// trayIcon is the widget of one tray item.
+type trayIcon struct {
+	box   *gtk.EventBox
+	image *gtk.Image
+	item  tray.Item
+	// menuPosition is the side of the icon its menu opens on.
+	menuPosition gtk.PositionType
+}
+
+// newTrayModule creates the system tray, which shows the StatusNotifierItems
+// of applications such as Nextcloud, Steam or nm-applet.
+func newTrayModule(ctx ModuleContext, _ config.Module) (*Module, error) {
+	box, _ := gtk.BoxNew(ctx.Orientation(), 0)
+	sc, _ := box.GetStyleContext()
+	sc.AddClass("tray")
+
+	icons := make(map[*tray.Handle]*trayIcon)
+	unsubscribe := getTray().subscribe(func(e tray.Event) {
+		h := e.Item.Handle
+		switch e.Kind {
+		case tray.Added:
+			icon := newTrayIcon(ctx.PopoverPosition())
+			icons[h] = icon
+			box.PackStart(icon.box, false, false, 0)
+			icon.update(e.Item)
+		case tray.Changed:
+			if icon, ok := icons[h]; ok {
+				icon.update(e.Item)
+			}
+		case tray.Removed:
+			if icon, ok := icons[h]; ok {
+				icon.box.Destroy()
+				delete(icons, h)
+			}
+		}
+	})
+	box.Connect("destroy", unsubscribe)
+
+	return &Module{Widget: box}, nil
+}
+
+func newTrayIcon(menuPosition gtk.PositionType) *trayIcon {
+	icon := &trayIcon{menuPosition: menuPosition}
+	icon.box, _ = gtk.EventBoxNew()
+	icon.box.AddEvents(int(gdk.SCROLL_MASK))
+	icon.box.SetNoShowAll(true)
+	sc, _ := icon.box.GetStyleContext()
+	sc.AddClass("tray-item")
+
+	icon.image, _ = gtk.ImageNew()
+	icon.box.Add(icon.image)
+
+	icon.box.Connect("button-press-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+		return icon.clicked(ev)
+	})
+	icon.box.Connect("scroll-event", func(_ *gtk.EventBox, ev *gdk.Event) bool {
+		delta, horizontal := 0, false
+		switch gdk.EventScrollNewFromEvent(ev).Direction() {
+		case gdk.SCROLL_UP:
+			delta = -1
+		case gdk.SCROLL_DOWN:
+			delta = 1
+		case gdk.SCROLL_LEFT:
+			delta, horizontal = -1, true
+		case gdk.SCROLL_RIGHT:
+			delta, horizontal = 1, true
+		default:
+			return false
+		}
+		handle := icon.item.Handle
+		go func() { logTrayError("scroll", handle.Scroll(delta, horizontal)) }()
+		return true
+	})
+	return icon
+}
+
+func (icon *trayIcon) update(item tray.Item) {
+	icon.item = item
+
+	name, pixmaps := item.IconName, item.IconPixmap
+	sc, _ := icon.box.GetStyleContext()
+	if item.Status == tray.StatusNeedsAttention {
+		sc.AddClass("needs-attention")
+		if item.AttentionIconName != "" || len(item.AttentionIconPixmap) > 0 {
+			name, pixmaps = item.AttentionIconName, item.AttentionIconPixmap
+		}
+	} else {
+		sc.RemoveClass("needs-attention")
+	}
+	getTray().addThemePath(item.IconThemePath)
+	setTrayImage(icon.image, name, pixmaps)
+
+	tooltip := strings.TrimSpace(item.ToolTip.Title + "\n" + item.ToolTip.Description)
+	if tooltip == "" {
+		tooltip = item.Title
+	}
+	icon.box.SetTooltipText(tooltip)
+
+	if item.Status == tray.StatusPassive {
+		icon.box.Hide()
+	} else {
+		icon.box.ShowAll()
+	}
+}
+
+// setTrayImage shows the named icon in img, or the pixmap closest to the
+// tray icon size if the icon theme does not have it.
+func setTrayImage(img *gtk.Image, name string, pixmaps []tray.Pixmap) {
+	if filepath.IsAbs(name) {
+		if pixbuf, err := gdk.PixbufNewFromFileAtScale(name, trayIconSize, trayIconSize, true); err == nil {
+			img.SetFromPixbuf(pixbuf)
+			return
+		}
+	} else if name != "" {
+		if theme, err := gtk.IconThemeGetDefault(); err == nil && theme.HasIcon(name) {
+			img.SetFromIconName(name, gtk.ICON_SIZE_BUTTON)
+			img.SetPixelSize(trayIconSize)
+			return
+		}
+	}
+
+	if p, ok := tray.BestPixmap(pixmaps, trayIconSize); ok {
+		pixbuf, err := pixbufFromData(p.RGBA(), true, p.Width, p.Height, p.Width*4)
+		if err == nil {
+			if p.Width > trayIconSize || p.Height > trayIconSize {
+				pixbuf = scalePixbuf(pixbuf, trayIconSize, trayIconSize)
+			}
+			img.SetFromPixbuf(pixbuf)
+			return
+		}
+	}
+
+	img.SetFromIconName(fallbackAppIcon, gtk.ICON_SIZE_BUTTON)
+	img.SetPixelSize(trayIconSize)
+}
+
+func (icon *trayIcon) clicked(ev *gdk.Event) bool {
+	button := gdk.EventButtonNewFromEvent(ev)
+	item := icon.item
+	x, y := int(button.XRoot()), int(button.YRoot())
+
+	switch button.Button() {
+	case gdk.BUTTON_PRIMARY:
+		if item.ItemIsMenu && item.HasMenu {
+			icon.showMenu()
+		} else {
+			go func() { logTrayError("activate", item.Handle.Activate(x, y)) }()
+		}
+	case gdk.BUTTON_MIDDLE:
+		go func() { logTrayError("activate", item.Handle.SecondaryActivate(x, y)) }()
+	case gdk.BUTTON_SECONDARY:
+		if item.HasMenu {
+			icon.showMenu()
+		} else {
+			go func() { logTrayError("open the menu of", item.Handle.ContextMenu(x, y)) }()
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// showMenu shows the DBusMenu of the item next to its icon. The menu is read
+// in the background, since applications may take a while to answer.
+func (icon *trayIcon) showMenu() {
+	handle := icon.item.Handle
+	go func() {
+		root, err := handle.Menu()
+		glib.IdleAdd(func() {
+			if err != nil {
+				logTrayError("read the menu of", err)
+				return
+			}
+			// The icon may be gone by now.
+			if !icon.box.GetMapped() {
+				return
+			}
+
+			menu := newContextMenu(trayMenuItems(handle, root.Children)...)
+			popupMenuAtWidget(menu, icon.box, icon.menuPosition)
+			go handle.MenuEvent(root.ID, tray.MenuOpened)
+			menu.Connect("deactivate", func() {
+				go handle.MenuEvent(root.ID, tray.MenuClosed)
+			})
+		})
+	}()
+}
+
+func trayMenuItems(handle *tray.Handle, entries []tray.MenuItem) []gtk.IMenuItem {
+	var items []gtk.IMenuItem
+	for _, entry := range entries {
+		if !entry.Visible {
+			continue
+		}
+		if entry.Separator {
+			items = append(items, menuSeparator())
+			continue
+		}
+
+		var item *gtk.MenuItem
+		switch entry.ToggleType {
+		case "checkmark", "radio":
+			check, _ := gtk.CheckMenuItemNewWithMnemonic(entry.Label)
+			check.SetDrawAsRadio(entry.ToggleType == "radio")
+			check.SetActive(entry.ToggleState == 1)
+			item = &check.MenuItem
+		default:
+			item, _ = gtk.MenuItemNewWithMnemonic(entry.Label)
+		}
+		item.SetSensitive(entry.Enabled)
+
+		if len(entry.Children) > 0 {
+			submenu, _ := gtk.MenuNew()
+			for _, child := range trayMenuItems(handle, entry.Children) {
+				submenu.Append(child)
+			}
+			item.SetSubmenu(submenu)
+		} else {
+			id := entry.ID
+			item.Connect("activate", func() {
+				go handle.MenuEvent(id, tray.MenuClicked)
+			})
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func logTrayError(action string, err error) {
+	if err != nil {
+		log.Printf("Failed to %s tray item: %v", action, err)
+	}
+}
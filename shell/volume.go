@@ -0,0 +1,401 @@
+package shell
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/AuruTeam/desktop/audio"
+	"github.com/AuruTeam/desktoplib/volumeHandler"
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+	"github.com/gotk3/gotk3/pango"
+)
+
+// volumeStep is how much one scroll step on the volume icon changes the
+// volume.
+const volumeStep = 0.05
+
+// volumeControl is the volume icon of the status module. Scrolling on it
+// changes the volume, and clicking it opens a mixer for the outputs and the
+// applications playing sound.
+type volumeControl struct {
+	mixer *audio.Mixer
+	state audio.State
+
+	button  *gtk.Button
+	image   *gtk.Image
+	popover *gtk.Popover
+
+	slider  *gtk.Scale
+	mute    *gtk.ToggleButton
+	sinks   *gtk.ComboBoxText
+	streams *gtk.Box
+	rows    map[uint32]*streamRow
+
+	// requests are the changes waiting to be sent to the sound server.
+	requests requestQueue
+	// reading is set while the state is read in the background, and stale
+	// if it changed again meanwhile.
+	reading, stale bool
+	destroyed      bool
+
+	// updating is set while the widgets show a new state, so that their
+	// signals are not taken for the user changing the volume.
+	updating bool
+}
+
+// requestQueue sends requests to the sound server one after the other, away
+// from the main loop. A request replaces the waiting one with the same key,
+// so that dragging a slider does not pile up volume changes.
+type requestQueue struct {
+	mu      sync.Mutex
+	keys    []string
+	pending map[string]func()
+	running bool
+}
+
+func (q *requestQueue) add(key string, request func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		q.pending = make(map[string]func())
+	}
+	if _, ok := q.pending[key]; !ok {
+		q.keys = append(q.keys, key)
+	}
+	q.pending[key] = request
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+}
+
+func (q *requestQueue) run() {
+	for {
+		q.mu.Lock()
+		if len(q.keys) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		key := q.keys[0]
+		q.keys = q.keys[1:]
+		request := q.pending[key]
+		delete(q.pending, key)
+		q.mu.Unlock()
+
+		request()
+	}
+}
+
+// streamRow is the volume slider of one application in the mixer.
+type streamRow struct {
+	box    *gtk.Box
+	image  *gtk.Image
+	label  *gtk.Label
+	slider *gtk.Scale
+	mute   *gtk.ToggleButton
+	stream audio.Stream
+}
+
+// newVolumeControl creates the volume icon with its mixer popover.
+func newVolumeControl(ctx ModuleContext, mixer *audio.Mixer) *volumeControl {
+	v := &volumeControl{mixer: mixer, rows: make(map[uint32]*streamRow)}
+
+	v.button, _ = gtk.ButtonNew()
+	v.button.SetRelief(gtk.RELIEF_NONE)
+	v.button.AddEvents(int(gdk.SCROLL_MASK))
+	v.image, _ = gtk.ImageNew()
+	sc, _ := v.image.GetStyleContext()
+	sc.AddClass("sound")
+	v.button.Add(v.image)
+
+	v.popover, _ = gtk.PopoverNew(v.button)
+	v.popover.SetPosition(ctx.PopoverPosition())
+	sc, _ = v.popover.GetStyleContext()
+	sc.AddClass("volume-mixer")
+	v.popover.Add(v.createMixer())
+
+	v.button.Connect("clicked", func() {
+		if v.popover.IsVisible() {
+			v.popover.Popdown()
+		} else {
+			v.popover.ShowAll()
+			v.popover.Popup()
+		}
+	})
+	v.button.Connect("button-press-event", func(_ *gtk.Button, ev *gdk.Event) bool {
+		// Middle-click mutes, like in most volume applets.
+		if gdk.EventButtonNewFromEvent(ev).Button() != gdk.BUTTON_MIDDLE {
+			return false
+		}
+		if sink, ok := v.state.DefaultSink(); ok {
+			v.setSinkMute(sink, !sink.Muted)
+		}
+		return true
+	})
+	v.button.Connect("scroll-event", func(_ *gtk.Button, ev *gdk.Event) bool {
+		sink, ok := v.state.DefaultSink()
+		if !ok {
+			return false
+		}
+		switch gdk.EventScrollNewFromEvent(ev).Direction() {
+		case gdk.SCROLL_UP, gdk.SCROLL_RIGHT:
+			v.setSinkVolume(sink, math.Max(sink.Volume, math.Min(sink.Volume+volumeStep, 1)))
+		case gdk.SCROLL_DOWN, gdk.SCROLL_LEFT:
+			v.setSinkVolume(sink, math.Max(sink.Volume-volumeStep, 0))
+		default:
+			return false
+		}
+		return true
+	})
+	v.button.Connect("destroy", func() {
+		v.destroyed = true
+		v.popover.Destroy()
+		v.mixer.Close()
+	})
+	return v
+}
+
+// createMixer creates the content of the popover: the output selector, the
+// master volume and one row per application.
+func (v *volumeControl) createMixer() *gtk.Box {
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 10)
+
+	v.sinks, _ = gtk.ComboBoxTextNew()
+	// The selector and the application list are only shown when there is
+	// something to choose from.
+	v.sinks.SetNoShowAll(true)
+	v.sinks.Connect("changed", func() {
+		if v.updating {
+			return
+		}
+		if name := v.sinks.GetActiveID(); name != "" && name != v.state.Default {
+			v.requests.add("default sink", func() {
+				logVolumeError("switch the output device", v.mixer.SetDefaultSink(name))
+			})
+		}
+	})
+	box.PackStart(v.sinks, false, false, 0)
+
+	master, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	sc, _ := master.GetStyleContext()
+	sc.AddClass("volume-master")
+	v.mute = newMuteButton()
+	v.mute.Connect("toggled", func() {
+		if sink, ok := v.state.DefaultSink(); ok && !v.updating {
+			v.setSinkMute(sink, v.mute.GetActive())
+		}
+	})
+	v.slider = newVolumeSlider()
+	v.slider.Connect("value-changed", func() {
+		if sink, ok := v.state.DefaultSink(); ok && !v.updating {
+			v.setSinkVolume(sink, v.slider.GetValue()/100)
+		}
+	})
+	master.PackStart(v.mute, false, false, 0)
+	master.PackStart(v.slider, true, true, 0)
+	box.PackStart(master, false, false, 0)
+
+	v.streams, _ = gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 5)
+	sc, _ = v.streams.GetStyleContext()
+	sc.AddClass("volume-streams")
+	v.streams.SetNoShowAll(true)
+	box.PackStart(v.streams, false, false, 0)
+	return box
+}
+
+func newMuteButton() *gtk.ToggleButton {
+	mute, _ := gtk.ToggleButtonNew()
+	mute.SetRelief(gtk.RELIEF_NONE)
+	mute.SetTooltipText("Mute")
+	image, _ := gtk.ImageNewFromIconName("audio-volume-muted-symbolic", gtk.ICON_SIZE_BUTTON)
+	mute.Add(image)
+	return mute
+}
+
+func newVolumeSlider() *gtk.Scale {
+	slider, _ := gtk.ScaleNewWithRange(gtk.ORIENTATION_HORIZONTAL, 0, 100, 1)
+	slider.SetDrawValue(false)
+	slider.SetSizeRequest(200, -1)
+	return slider
+}
+
+// update refreshes the icon and the mixer from the sound server. The state
+// is read in the background; changes that come in meanwhile are read once
+// it is done.
+func (v *volumeControl) update() {
+	if v.reading {
+		v.stale = true
+		return
+	}
+	v.reading = true
+
+	go func() {
+		icon, iconErr := volumeHandler.GetAudioIcon()
+		state, err := v.mixer.State()
+		glib.IdleAdd(func() {
+			v.reading = false
+			if v.destroyed {
+				return
+			}
+			if iconErr == nil {
+				v.image.SetFromIconName(icon, gtk.ICON_SIZE_BUTTON)
+			}
+			if err != nil {
+				log.Println("Error reading the volume:", err)
+			} else {
+				v.show(state)
+			}
+			if v.stale {
+				v.stale = false
+				v.update()
+			}
+		})
+	}()
+}
+
+// show makes the mixer show state.
+func (v *volumeControl) show(state audio.State) {
+	old := v.state
+	v.state = state
+
+	v.updating = true
+	defer func() { v.updating = false }()
+
+	sink, ok := state.DefaultSink()
+	if ok {
+		v.button.SetTooltipText(fmt.Sprintf("%s: %d%%", sink.Description, percent(sink.Volume)))
+	}
+	v.slider.SetSensitive(ok)
+	v.mute.SetSensitive(ok)
+	v.slider.SetValue(float64(percent(sink.Volume)))
+	v.mute.SetActive(sink.Muted)
+
+	// Refilling the selector would close it if it is open.
+	if !slices.EqualFunc(old.Sinks, state.Sinks, sameSink) {
+		v.sinks.RemoveAll()
+		for _, s := range state.Sinks {
+			v.sinks.Append(s.Name, s.Description)
+		}
+	}
+	v.sinks.SetActiveID(state.Default)
+	v.sinks.SetVisible(len(state.Sinks) > 1)
+
+	seen := make(map[uint32]bool)
+	for _, stream := range state.Streams {
+		seen[stream.Index] = true
+		row, ok := v.rows[stream.Index]
+		if !ok {
+			row = v.newStreamRow(stream.Index)
+			v.rows[stream.Index] = row
+		}
+		row.update(stream)
+	}
+	for index, row := range v.rows {
+		if !seen[index] {
+			row.box.Destroy()
+			delete(v.rows, index)
+		}
+	}
+	v.streams.SetVisible(len(v.rows) > 0)
+}
+
+func sameSink(a, b audio.Sink) bool {
+	return a.Name == b.Name && a.Description == b.Description
+}
+
+func (v *volumeControl) newStreamRow(index uint32) *streamRow {
+	row := &streamRow{}
+	row.box, _ = gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 5)
+	sc, _ := row.box.GetStyleContext()
+	sc.AddClass("volume-stream")
+
+	row.image, _ = gtk.ImageNew()
+	row.label, _ = gtk.LabelNew("")
+	row.label.SetXAlign(0)
+	row.label.SetWidthChars(12)
+	row.label.SetMaxWidthChars(12)
+	row.label.SetEllipsize(pango.ELLIPSIZE_END)
+
+	row.mute = newMuteButton()
+	row.mute.Connect("toggled", func() {
+		if !v.updating {
+			muted := row.mute.GetActive()
+			v.requests.add(fmt.Sprint("stream mute ", index), func() {
+				logVolumeError("mute the application", v.mixer.SetStreamMute(index, muted))
+			})
+		}
+	})
+	row.slider = newVolumeSlider()
+	row.slider.Connect("value-changed", func() {
+		if !v.updating {
+			stream, volume := row.stream, row.slider.GetValue()/100
+			v.requests.add(fmt.Sprint("stream volume ", index), func() {
+				logVolumeError("change the volume of the application", v.mixer.SetStreamVolume(stream, volume))
+			})
+		}
+	})
+
+	row.box.PackStart(row.image, false, false, 0)
+	row.box.PackStart(row.label, false, false, 0)
+	row.box.PackStart(row.mute, false, false, 0)
+	row.box.PackStart(row.slider, true, true, 0)
+	v.streams.PackStart(row.box, false, false, 0)
+	row.box.ShowAll()
+	return row
+}
+
+func (row *streamRow) update(stream audio.Stream) {
+	row.stream = stream
+	icon := stream.IconName
+	if icon == "" {
+		icon = fallbackAppIcon
+	}
+	row.image.SetFromIconName(icon, gtk.ICON_SIZE_BUTTON)
+	row.label.SetText(stream.Application)
+	row.box.SetTooltipText(stream.Title)
+	row.slider.SetValue(float64(percent(stream.Volume)))
+	row.mute.SetActive(stream.Muted)
+}
+
+// setSinkVolume queues a volume change and takes it for done right away, so
+// that scroll steps that come in before the state is read again add up.
+func (v *volumeControl) setSinkVolume(sink audio.Sink, volume float64) {
+	v.requests.add(fmt.Sprint("sink volume ", sink.Index), func() {
+		logVolumeError("change the volume", v.mixer.SetSinkVolume(sink, volume))
+	})
+	v.changeSink(sink.Index, func(s *audio.Sink) { s.Volume = volume })
+}
+
+func (v *volumeControl) setSinkMute(sink audio.Sink, muted bool) {
+	v.requests.add(fmt.Sprint("sink mute ", sink.Index), func() {
+		logVolumeError("mute", v.mixer.SetSinkMute(sink.Index, muted))
+	})
+	v.changeSink(sink.Index, func(s *audio.Sink) { s.Muted = muted })
+}
+
+// changeSink applies change to the sink with the given index in the last
+// read state.
+func (v *volumeControl) changeSink(index uint32, change func(*audio.Sink)) {
+	for i := range v.state.Sinks {
+		if v.state.Sinks[i].Index == index {
+			change(&v.state.Sinks[i])
+		}
+	}
+}
+
+// percent rounds a volume to whole percents.
+func percent(volume float64) int {
+	return int(math.Round(volume * 100))
+}
+
+func logVolumeError(action string, err error) {
+	if err != nil {
+		log.Printf("Failed to %s: %v", action, err)
+	}
+}
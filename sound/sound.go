@@ -0,0 +1,148 @@
+// Package sound plays event sounds, such as the ones notifications ask for,
+// by file or by name in the freedesktop sound theme.
+package sound
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when a sound name has no file in the sound
+	// theme.
+	ErrNotFound = errors.New("sound: sound not found in the sound theme")
+	// ErrNoCommand is returned by a CommandPlayer without a command.
+	ErrNoCommand = errors.New("sound: no player command")
+	// ErrUnsupported is returned for files a CommandPlayer cannot play.
+	ErrUnsupported = errors.New("sound: file type not supported by the player")
+)
+
+// extensions are the file types of sound themes, in order of preference.
+var extensions = []string{".oga", ".ogg", ".wav"}
+
+// A Player plays sounds. Its methods return once the sound has started.
+type Player interface {
+	// PlayFile plays the sound file at path.
+	PlayFile(path string) error
+	// PlayName plays the sound with the given name in the sound theme,
+	// such as message-new-instant.
+	PlayName(name string) error
+}
+
+// CommandPlayer plays sound files with an external program, such as
+// pw-play or paplay, and looks names up in the sound theme itself.
+type CommandPlayer struct {
+	// Command is the program and its arguments; the path of the file is
+	// appended.
+	Command []string
+	// Theme is the sound theme names are looked up in; freedesktop if
+	// empty.
+	Theme string
+	// Extensions are the file types the program plays, such as .wav; all
+	// types of sound themes if empty.
+	Extensions []string
+}
+
+// System returns a player using the first of pw-play, paplay and aplay that
+// is installed, or nil if there is none. aplay only plays WAV files.
+func System() Player {
+	for _, program := range []string{"pw-play", "paplay", "aplay"} {
+		if path, err := exec.LookPath(program); err == nil {
+			p := &CommandPlayer{Command: []string{path}}
+			if program == "aplay" {
+				p.Extensions = []string{".wav"}
+			}
+			return p
+		}
+	}
+	return nil
+}
+
+// PlayFile plays the sound file at path.
+func (p *CommandPlayer) PlayFile(path string) error {
+	if len(p.Command) == 0 {
+		return ErrNoCommand
+	}
+	if p.Extensions != nil && !slices.Contains(p.Extensions, strings.ToLower(filepath.Ext(path))) {
+		return ErrUnsupported
+	}
+	args := append(p.Command[1:len(p.Command):len(p.Command)], path)
+	cmd := exec.Command(p.Command[0], args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// PlayName plays the sound with the given name in the sound theme.
+func (p *CommandPlayer) PlayName(name string) error {
+	theme := p.Theme
+	if theme == "" {
+		theme = "freedesktop"
+	}
+	path, ok := Lookup(theme, name, p.Extensions...)
+	if !ok {
+		return ErrNotFound
+	}
+	return p.PlayFile(path)
+}
+
+// Lookup finds the file of a sound in a sound theme, falling back to the
+// freedesktop theme. As the sound naming specification asks, a name that is
+// not found is shortened at its last dash and looked up again, so
+// message-new-email falls back to message-new and message. Only files with
+// the given extensions are considered, or the ones of sound themes if there
+// are none.
+func Lookup(theme, name string, exts ...string) (string, bool) {
+	if len(exts) == 0 {
+		exts = extensions
+	}
+	themes := []string{theme}
+	if theme != "freedesktop" {
+		themes = append(themes, "freedesktop")
+	}
+	for name != "" {
+		for _, t := range themes {
+			for _, dir := range dataDirs() {
+				for _, ext := range exts {
+					path := filepath.Join(dir, "sounds", t, "stereo", name+ext)
+					if _, err := os.Stat(path); err == nil {
+						return path, true
+					}
+				}
+			}
+		}
+		i := strings.LastIndex(name, "-")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return "", false
+}
+
+// dataDirs returns the XDG data directories in order of precedence.
+func dataDirs() []string {
+	var dirs []string
+	if home := os.Getenv("XDG_DATA_HOME"); home != "" {
+		dirs = append(dirs, home)
+	} else if h, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(h, ".local", "share"))
+	}
+
+	system := os.Getenv("XDG_DATA_DIRS")
+	if system == "" {
+		system = "/usr/local/share:/usr/share"
+	}
+	for _, d := range strings.Split(system, ":") {
+		if d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
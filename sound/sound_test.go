@@ -0,0 +1,63 @@
+package sound
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", home)
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(home, "missing"))
+	for _, file := range []string{
+		"freedesktop/stereo/message.oga",
+		"freedesktop/stereo/message.wav",
+		"freedesktop/stereo/bell.oga",
+		"custom/stereo/message-new.ogg",
+	} {
+		path := filepath.Join(home, "sounds", file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stereo := func(theme, file string) string {
+		return filepath.Join(home, "sounds", theme, "stereo", file)
+	}
+
+	tests := []struct {
+		theme, name string
+		exts        []string
+		want        string
+	}{
+		{"freedesktop", "message", nil, stereo("freedesktop", "message.oga")},
+		{"freedesktop", "message-new-email", nil, stereo("freedesktop", "message.oga")},
+		{"custom", "message-new-email", nil, stereo("custom", "message-new.ogg")},
+		{"custom", "bell", nil, stereo("freedesktop", "bell.oga")},
+		{"freedesktop", "message", []string{".wav"}, stereo("freedesktop", "message.wav")},
+		{"custom", "message-new", []string{".wav"}, stereo("freedesktop", "message.wav")},
+		{"freedesktop", "bell", []string{".wav"}, ""},
+	}
+	for _, tt := range tests {
+		got, ok := Lookup(tt.theme, tt.name, tt.exts...)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Lookup(%q, %q, %q) = %q, %v, want %q", tt.theme, tt.name, tt.exts, got, ok, tt.want)
+		}
+	}
+}
+
+func TestCommandPlayerErrors(t *testing.T) {
+	var p CommandPlayer
+	if err := p.PlayFile("bell.wav"); !errors.Is(err, ErrNoCommand) {
+		t.Errorf("PlayFile without a command = %v, want ErrNoCommand", err)
+	}
+
+	p = CommandPlayer{Command: []string{"true"}, Extensions: []string{".wav"}}
+	if err := p.PlayFile("bell.oga"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("PlayFile of an .oga file by a WAV player = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,43 @@
+package status
+
+import (
+	"github.com/jfreymuth/pulse/proto"
+)
+
+type audioSource struct{}
+
+// Audio watches the sound server for sink volume, mute and default sink
+// changes. It works with PulseAudio as well as with PipeWire through
+// pipewire-pulse.
+func Audio() Source {
+	return audioSource{}
+}
+
+func (audioSource) Watch(changed func()) (func(), error) {
+	client, conn, err := proto.Connect("")
+	if err != nil {
+		return nil, err
+	}
+
+	client.Callback = func(msg interface{}) {
+		if _, ok := msg.(*proto.SubscribeEvent); ok {
+			changed()
+		}
+	}
+
+	err = client.Request(&proto.SetClientName{Props: proto.PropList{
+		"application.name": proto.PropListString("auru-shell"),
+	}}, &proto.SetClientNameReply{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	err = client.Request(&proto.Subscribe{Mask: proto.SubscriptionMaskSink | proto.SubscriptionMaskServer}, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() { conn.Close() }, nil
+}
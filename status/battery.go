@@ -0,0 +1,20 @@
+package status
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// Battery watches the UPower display device, which aggregates all system
+// batteries, for charge and state changes.
+func Battery() Source {
+	return dbusSource{
+		connect: dbus.ConnectSystemBus,
+		matches: [][]dbus.MatchOption{
+			{
+				dbus.WithMatchObjectPath("/org/freedesktop/UPower/devices/DisplayDevice"),
+				dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+				dbus.WithMatchMember("PropertiesChanged"),
+			},
+		},
+	}
+}
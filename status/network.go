@@ -0,0 +1,24 @@
+package status
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// Network watches NetworkManager for state and primary connection changes.
+func Network() Source {
+	return dbusSource{
+		connect: dbus.ConnectSystemBus,
+		matches: [][]dbus.MatchOption{
+			{
+				dbus.WithMatchObjectPath("/org/freedesktop/NetworkManager"),
+				dbus.WithMatchInterface("org.freedesktop.NetworkManager"),
+				dbus.WithMatchMember("StateChanged"),
+			},
+			{
+				dbus.WithMatchObjectPath("/org/freedesktop/NetworkManager"),
+				dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+				dbus.WithMatchMember("PropertiesChanged"),
+			},
+		},
+	}
+}
@@ -0,0 +1,48 @@
+// Package status watches the system state shown by the bar status icons
+// (audio, network and power) and reports when it changes, so the icons only
+// need to be refreshed on actual events instead of being polled.
+package status
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// A Source reports changes of one piece of system state.
+type Source interface {
+	// Watch calls changed from a background goroutine every time the
+	// watched state may have changed, until stop is called. It returns an
+	// error when the event source is not reachable; callers are then
+	// expected to fall back to polling.
+	Watch(changed func()) (stop func(), err error)
+}
+
+// dbusSource is a Source fed by D-Bus signals matching a set of rules.
+type dbusSource struct {
+	connect func(opts ...dbus.ConnOption) (*dbus.Conn, error)
+	matches [][]dbus.MatchOption
+}
+
+func (s dbusSource) Watch(changed func()) (func(), error) {
+	conn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range s.matches {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	go func() {
+		// The channel is closed together with the connection.
+		for range signals {
+			changed()
+		}
+	}()
+
+	return func() { conn.Close() }, nil
+}
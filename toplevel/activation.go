@@ -0,0 +1,73 @@
+package toplevel
+
+import "errors"
+
+const (
+	activationInterface = "xdg_activation_v1"
+	activationVersion   = 1
+)
+
+// ErrNoActivation is returned by ActivationToken when the compositor does
+// not support the xdg-activation protocol.
+var ErrNoActivation = errors.New("toplevel: compositor does not support " + activationInterface)
+
+// ActivationToken asks the compositor for an xdg-activation token, which
+// another program can use to focus one of its windows, as after a click on
+// a notification. appID is the application the token is for, or empty. It
+// blocks until the compositor answers.
+func ActivationToken(appID string) (string, error) {
+	c, err := dial()
+	if err != nil {
+		return "", err
+	}
+	defer c.close()
+
+	registry := c.newID()
+	c.send(1, 1, new(request).uint(registry))
+	roundtrip := c.newID()
+	c.send(1, 0, new(request).uint(roundtrip))
+	var activation uint32
+	for done := false; !done; {
+		e, err := c.read()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case e.sender == registry && e.opcode == 0:
+			name, iface, version := e.uint(), e.string(), e.uint()
+			if iface == activationInterface && activation == 0 {
+				activation = c.newID()
+				c.send(registry, 0, new(request).uint(name).string(iface).uint(min(version, activationVersion)).uint(activation))
+			}
+		case e.sender == roundtrip:
+			done = true
+		case e.sender == 1 && e.opcode == 0:
+			return "", displayError(e)
+		}
+	}
+	if activation == 0 {
+		return "", ErrNoActivation
+	}
+	defer c.send(activation, 0, nil) // destroy
+
+	token := c.newID()
+	c.send(activation, 1, new(request).uint(token)) // get_activation_token
+	if appID != "" {
+		c.send(token, 1, new(request).string(appID)) // set_app_id
+	}
+	c.send(token, 3, nil)       // commit
+	defer c.send(token, 4, nil) // destroy
+
+	for {
+		e, err := c.read()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case e.sender == token && e.opcode == 0: // done
+			return e.string(), nil
+		case e.sender == 1 && e.opcode == 0:
+			return "", displayError(e)
+		}
+	}
+}
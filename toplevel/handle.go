@@ -0,0 +1,78 @@
+package toplevel
+
+import (
+	"sync/atomic"
+)
+
+// Handle identifies a toplevel window and sends requests for it to the
+// compositor. Its methods may be called from any goroutine; they do nothing
+// once the toplevel has been closed.
+type Handle struct {
+	m  *Manager
+	id uint32
+
+	// The fields below are only accessed from the dispatch goroutine.
+	pending   Toplevel
+	outputs   []uint32
+	announced bool
+
+	closed atomic.Bool
+}
+
+func (h *Handle) send(opcode uint16, args *request) {
+	if h.closed.Load() {
+		return
+	}
+	h.m.conn.send(h.id, opcode, args)
+}
+
+// Activate focuses the toplevel, unminimizing it if needed.
+func (h *Handle) Activate() {
+	if h.m.seat == 0 {
+		return
+	}
+	h.send(4, new(request).uint(h.m.seat))
+}
+
+// SetMaximized asks the compositor to maximize or unmaximize the toplevel.
+func (h *Handle) SetMaximized(maximized bool) {
+	if maximized {
+		h.send(0, nil)
+	} else {
+		h.send(1, nil)
+	}
+}
+
+// SetMinimized asks the compositor to minimize or unminimize the toplevel.
+func (h *Handle) SetMinimized(minimized bool) {
+	if minimized {
+		h.send(2, nil)
+	} else {
+		h.send(3, nil)
+	}
+}
+
+// Close asks the toplevel to close. The application may ignore the request
+// or ask the user first.
+func (h *Handle) Close() {
+	h.send(5, nil)
+}
+
+// SupportsFullscreen reports whether the compositor implements
+// SetFullscreen.
+func (h *Handle) SupportsFullscreen() bool {
+	return h.m.version >= 2
+}
+
+// SetFullscreen asks the compositor to make the toplevel fullscreen on its
+// current output, or to leave fullscreen.
+func (h *Handle) SetFullscreen(fullscreen bool) {
+	if !h.SupportsFullscreen() {
+		return
+	}
+	if fullscreen {
+		h.send(8, new(request).uint(0))
+	} else {
+		h.send(9, nil)
+	}
+}
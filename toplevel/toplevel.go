@@ -0,0 +1,454 @@
+// Package toplevel tracks the windows of a wlroots-based compositor through
+// the wlr-foreign-toplevel-management protocol and reports every change as
+// an event, so that a taskbar can update its buttons in place.
+package toplevel
+
+import (
+	"cmp"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+)
+
+// Interface names and the highest versions this package understands.
+const (
+	managerInterface = "zwlr_foreign_toplevel_manager_v1"
+	managerVersion   = 3
+	seatInterface    = "wl_seat"
+	seatVersion      = 1
+	outputInterface  = "wl_output"
+	outputVersion    = 4
+
+	xdgOutputManagerInterface = "zxdg_output_manager_v1"
+	xdgOutputManagerVersion   = 3
+)
+
+// Protocol states reported by zwlr_foreign_toplevel_handle_v1.state.
+const (
+	stateMaximized  = 0
+	stateMinimized  = 1
+	stateActivated  = 2
+	stateFullscreen = 3
+)
+
+// ErrUnsupported is returned by Connect when the compositor does not offer
+// the foreign toplevel manager.
+var ErrUnsupported = errors.New("toplevel: compositor does not support " + managerInterface)
+
+// State is the window state of a toplevel.
+type State struct {
+	Maximized  bool
+	Minimized  bool
+	Activated  bool
+	Fullscreen bool
+}
+
+// Output is a compositor output as seen at the time of an event.
+type Output struct {
+	id          uint32
+	Name        string
+	Description string
+	// X and Y are the position of the output in the global compositor
+	// space in logical pixels, which is what GDK reports as the geometry
+	// of a monitor. Compositors without xdg-output may report 0,0 for
+	// every output.
+	X, Y int
+}
+
+// Toplevel is a snapshot of a toplevel window.
+type Toplevel struct {
+	Handle  *Handle
+	Title   string
+	AppID   string
+	State   State
+	Outputs []Output
+	Parent  *Handle
+}
+
+// OnOutput reports whether t is shown on the output o.
+func (t Toplevel) OnOutput(o Output) bool {
+	return slices.ContainsFunc(t.Outputs, func(to Output) bool { return to.id == o.id })
+}
+
+// Covers reports whether t is taken to cover the output o. The protocol
+// does not report window geometry, so this is the case for an activated
+// maximized or fullscreen toplevel on o.
+func (t Toplevel) Covers(o Output) bool {
+	return t.State.Activated && (t.State.Maximized || t.State.Fullscreen) && t.OnOutput(o)
+}
+
+// EventKind tells what happened to a toplevel.
+type EventKind int
+
+const (
+	// Added is sent once a new toplevel has reported its initial state.
+	Added EventKind = iota
+	// Changed is sent after the title, app_id, state, outputs or parent
+	// of a toplevel changed.
+	Changed
+	// Closed is sent when a toplevel has been unmapped. Its handle must
+	// not be used anymore.
+	Closed
+)
+
+// Event reports a change of a toplevel together with its new state.
+type Event struct {
+	Kind     EventKind
+	Toplevel Toplevel
+}
+
+// Manager is a connection to the compositor that tracks its toplevels.
+type Manager struct {
+	conn    *conn
+	handler func(Event)
+
+	registry  uint32
+	manager   uint32
+	version   uint32
+	seat      uint32
+	xdgOutput uint32
+
+	// outputs is written by the dispatch goroutine and read by Outputs.
+	outputsMu sync.Mutex
+	outputs   map[uint32]*Output
+
+	// The fields below are only accessed from the dispatch goroutine.
+	outputVer map[uint32]uint32
+	// xdgOutputs maps zxdg_output_v1 objects to their wl_output.
+	xdgOutputs map[uint32]uint32
+	globals    map[uint32]uint32
+	handles    map[uint32]*Handle
+
+	closeOnce sync.Once
+}
+
+// Connect connects to the compositor and starts tracking its toplevels.
+// handler is called for every event, in order. Toplevels that already exist
+// are reported as Added from within Connect; all later events come from a
+// background goroutine.
+func Connect(handler func(Event)) (*Manager, error) {
+	c, err := dial()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		conn:       c,
+		handler:    handler,
+		outputs:    make(map[uint32]*Output),
+		outputVer:  make(map[uint32]uint32),
+		xdgOutputs: make(map[uint32]uint32),
+		globals:    make(map[uint32]uint32),
+		handles:    make(map[uint32]*Handle),
+	}
+
+	// Collect the globals with a roundtrip before binding anything.
+	type global struct {
+		name, version uint32
+	}
+	offered := make(map[string]global)
+	var outputs []global
+	m.registry = c.newID()
+	c.send(1, 1, new(request).uint(m.registry))
+	roundtrip := c.newID()
+	c.send(1, 0, new(request).uint(roundtrip))
+	for done := false; !done; {
+		e, err := c.read()
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		switch {
+		case e.sender == m.registry && e.opcode == 0:
+			name, iface, version := e.uint(), e.string(), e.uint()
+			if iface == outputInterface {
+				outputs = append(outputs, global{name, version})
+			} else if _, ok := offered[iface]; !ok {
+				offered[iface] = global{name, version}
+			}
+		case e.sender == roundtrip:
+			done = true
+		case e.sender == 1 && e.opcode == 0:
+			c.close()
+			return nil, displayError(e)
+		}
+	}
+
+	mg, ok := offered[managerInterface]
+	if !ok {
+		c.close()
+		return nil, ErrUnsupported
+	}
+
+	if sg, ok := offered[seatInterface]; ok {
+		m.seat = m.bind(sg.name, seatInterface, min(sg.version, seatVersion))
+	}
+	// wl_output reports the position of an output in compositor-specific
+	// units, and wlroots-based compositors and Hyprland report 0,0, so the
+	// positions are taken from xdg-output.
+	if xg, ok := offered[xdgOutputManagerInterface]; ok {
+		m.xdgOutput = m.bind(xg.name, xdgOutputManagerInterface, min(xg.version, xdgOutputManagerVersion))
+	}
+	for _, og := range outputs {
+		m.bindOutput(og.name, og.version)
+	}
+	m.version = min(mg.version, managerVersion)
+	m.manager = m.bind(mg.name, managerInterface, m.version)
+
+	// Process the initial output and toplevel events before returning, so
+	// Outputs is complete right away.
+	roundtrip = c.newID()
+	c.send(1, 0, new(request).uint(roundtrip))
+	for {
+		e, err := c.read()
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+		if e.sender == roundtrip {
+			break
+		}
+		if !m.dispatchEvent(e) {
+			m.Close()
+			return nil, errors.New("toplevel: compositor stopped the toplevel manager")
+		}
+	}
+
+	go m.dispatch()
+	return m, nil
+}
+
+// Close stops tracking toplevels and disconnects from the compositor.
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() {
+		m.conn.send(m.manager, 0, nil)
+		m.conn.close()
+	})
+}
+
+// Outputs returns the outputs of the compositor.
+func (m *Manager) Outputs() []Output {
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+
+	outputs := make([]Output, 0, len(m.outputs))
+	for _, o := range m.outputs {
+		outputs = append(outputs, *o)
+	}
+	slices.SortFunc(outputs, func(a, b Output) int {
+		return cmp.Or(cmp.Compare(a.X, b.X), cmp.Compare(a.Y, b.Y))
+	})
+	return outputs
+}
+
+func (m *Manager) bind(name uint32, iface string, version uint32) uint32 {
+	id := m.conn.newID()
+	m.conn.send(m.registry, 0, new(request).uint(name).string(iface).uint(version).uint(id))
+	return id
+}
+
+func (m *Manager) bindOutput(name, version uint32) {
+	version = min(version, outputVersion)
+	id := m.bind(name, outputInterface, version)
+	m.outputsMu.Lock()
+	m.outputs[id] = &Output{id: id}
+	m.outputsMu.Unlock()
+	m.outputVer[id] = version
+	m.globals[name] = id
+
+	if m.xdgOutput != 0 {
+		xdgID := m.conn.newID()
+		m.conn.send(m.xdgOutput, 1, new(request).uint(xdgID).uint(id)) // get_xdg_output
+		m.xdgOutputs[xdgID] = id
+	}
+}
+
+func displayError(e *event) error {
+	object, code, message := e.uint(), e.uint(), e.string()
+	return &ProtocolError{Object: object, Code: code, Message: message}
+}
+
+// ProtocolError is a fatal error reported by the compositor.
+type ProtocolError struct {
+	Object  uint32
+	Code    uint32
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return "toplevel: protocol error: " + e.Message
+}
+
+func (m *Manager) dispatch() {
+	defer m.Close()
+	for {
+		e, err := m.conn.read()
+		if err != nil {
+			return
+		}
+		if !m.dispatchEvent(e) {
+			return
+		}
+	}
+}
+
+// dispatchEvent handles one event. It reports false once the connection is
+// no longer usable.
+func (m *Manager) dispatchEvent(e *event) bool {
+	switch {
+	case e.sender == 1:
+		if e.opcode == 0 {
+			log.Println("Toplevel manager disconnected:", displayError(e))
+			return false
+		}
+	case e.sender == m.registry:
+		m.registryEvent(e)
+	case e.sender == m.manager:
+		return m.managerEvent(e)
+	case m.outputVer[e.sender] != 0:
+		m.outputEvent(e)
+	case m.xdgOutputs[e.sender] != 0:
+		m.xdgOutputEvent(e)
+	case m.handles[e.sender] != nil:
+		m.handleEvent(m.handles[e.sender], e)
+	}
+	return true
+}
+
+func (m *Manager) registryEvent(e *event) {
+	switch e.opcode {
+	case 0: // global
+		name, iface, version := e.uint(), e.string(), e.uint()
+		if iface == outputInterface {
+			m.bindOutput(name, version)
+		}
+	case 1: // global_remove
+		name := e.uint()
+		if id, ok := m.globals[name]; ok {
+			for xdgID, outputID := range m.xdgOutputs {
+				if outputID == id {
+					m.conn.send(xdgID, 0, nil) // zxdg_output_v1.destroy
+					delete(m.xdgOutputs, xdgID)
+				}
+			}
+			if m.outputVer[id] >= 3 {
+				m.conn.send(id, 0, nil) // wl_output.release
+			}
+			delete(m.globals, name)
+			m.outputsMu.Lock()
+			delete(m.outputs, id)
+			m.outputsMu.Unlock()
+			delete(m.outputVer, id)
+		}
+	}
+}
+
+func (m *Manager) managerEvent(e *event) bool {
+	switch e.opcode {
+	case 0: // toplevel
+		id := e.uint()
+		m.handles[id] = &Handle{m: m, id: id}
+	case 1: // finished
+		return false
+	}
+	return true
+}
+
+func (m *Manager) outputEvent(e *event) {
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+
+	o := m.outputs[e.sender]
+	switch e.opcode {
+	case 0: // geometry
+		if m.xdgOutput == 0 {
+			o.X, o.Y = int(e.int()), int(e.int())
+		}
+	case 4: // name
+		o.Name = e.string()
+	case 5: // description
+		o.Description = e.string()
+	}
+}
+
+func (m *Manager) xdgOutputEvent(e *event) {
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+
+	o := m.outputs[m.xdgOutputs[e.sender]]
+	switch e.opcode {
+	case 0: // logical_position
+		o.X, o.Y = int(e.int()), int(e.int())
+	case 3: // name
+		o.Name = e.string()
+	case 4: // description
+		o.Description = e.string()
+	}
+}
+
+func (m *Manager) handleEvent(h *Handle, e *event) {
+	switch e.opcode {
+	case 0: // title
+		h.pending.Title = e.string()
+	case 1: // app_id
+		h.pending.AppID = e.string()
+	case 2: // output_enter
+		id := e.uint()
+		if !slices.Contains(h.outputs, id) {
+			h.outputs = append(h.outputs, id)
+		}
+	case 3: // output_leave
+		id := e.uint()
+		h.outputs = slices.DeleteFunc(h.outputs, func(o uint32) bool { return o == id })
+	case 4: // state
+		data := e.array()
+		var state State
+		for len(data) >= 4 {
+			ev := event{data: data[:4]}
+			switch ev.uint() {
+			case stateMaximized:
+				state.Maximized = true
+			case stateMinimized:
+				state.Minimized = true
+			case stateActivated:
+				state.Activated = true
+			case stateFullscreen:
+				state.Fullscreen = true
+			}
+			data = data[4:]
+		}
+		h.pending.State = state
+	case 5: // done
+		kind := Changed
+		if !h.announced {
+			kind = Added
+			h.announced = true
+		}
+		m.handler(Event{Kind: kind, Toplevel: m.snapshot(h)})
+	case 6: // closed
+		h.closed.Store(true)
+		delete(m.handles, h.id)
+		m.conn.send(h.id, 7, nil) // destroy
+		if h.announced {
+			m.handler(Event{Kind: Closed, Toplevel: m.snapshot(h)})
+		}
+	case 7: // parent
+		parent := e.uint()
+		h.pending.Parent = m.handles[parent]
+	}
+}
+
+func (m *Manager) snapshot(h *Handle) Toplevel {
+	t := h.pending
+	t.Handle = h
+	t.Outputs = nil
+	m.outputsMu.Lock()
+	defer m.outputsMu.Unlock()
+	for _, id := range h.outputs {
+		if o, ok := m.outputs[id]; ok {
+			t.Outputs = append(t.Outputs, *o)
+		}
+	}
+	return t
+}
@@ -0,0 +1,131 @@
+package toplevel
+
+import (
+	"slices"
+	"testing"
+)
+
+// newTestManager returns a manager with the given outputs bound, each with
+// an xdg-output, that records the events it reports.
+func newTestManager(outputs int) (*Manager, *[]Event) {
+	var events []Event
+	m := &Manager{
+		handler:    func(e Event) { events = append(events, e) },
+		xdgOutput:  2,
+		outputs:    make(map[uint32]*Output),
+		outputVer:  make(map[uint32]uint32),
+		xdgOutputs: make(map[uint32]uint32),
+		globals:    make(map[uint32]uint32),
+		handles:    make(map[uint32]*Handle),
+	}
+	for i := range uint32(outputs) {
+		id := 10 + i
+		m.outputs[id] = &Output{id: id}
+		m.outputVer[id] = outputVersion
+		m.xdgOutputs[20+i] = id
+	}
+	return m, &events
+}
+
+func deliver(m *Manager, sender uint32, opcode uint16, args *request) {
+	var data []byte
+	if args != nil {
+		data = args.buf
+	}
+	m.dispatchEvent(&event{sender: sender, opcode: opcode, data: data})
+}
+
+func TestOutputPositions(t *testing.T) {
+	m, _ := newTestManager(2)
+
+	// Like wlroots-based compositors, report both outputs at 0,0 through
+	// wl_output.
+	for _, id := range []uint32{10, 11} {
+		deliver(m, id, 0, new(request).int(0).int(0).int(600).int(340).int(0).string("").string("").int(0))
+	}
+	deliver(m, 20, 0, new(request).int(1920).int(0))
+	deliver(m, 20, 3, new(request).string("DP-1"))
+	deliver(m, 21, 0, new(request).int(0).int(0))
+	deliver(m, 21, 3, new(request).string("HDMI-A-1"))
+	deliver(m, 21, 4, new(request).string("Dell U2720Q"))
+
+	got := m.Outputs()
+	want := []Output{
+		{id: 11, Name: "HDMI-A-1", Description: "Dell U2720Q", X: 0, Y: 0},
+		{id: 10, Name: "DP-1", X: 1920, Y: 0},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Outputs = %+v, want %+v", got, want)
+	}
+}
+
+func TestOutputPositionsWithoutXDGOutput(t *testing.T) {
+	m, _ := newTestManager(1)
+	m.xdgOutput = 0
+	deliver(m, 10, 0, new(request).int(1920).int(1080).int(600).int(340).int(0).string("").string("").int(0))
+
+	if got := m.Outputs(); len(got) != 1 || got[0].X != 1920 || got[0].Y != 1080 {
+		t.Errorf("Outputs = %+v, want the position from wl_output", got)
+	}
+}
+
+func TestOnOutput(t *testing.T) {
+	m, events := newTestManager(2)
+	deliver(m, 20, 0, new(request).int(0).int(0))
+	deliver(m, 21, 0, new(request).int(1920).int(0))
+	m.handles[30] = &Handle{m: m, id: 30}
+
+	deliver(m, 30, 2, new(request).uint(11)) // output_enter
+	deliver(m, 30, 5, nil)                   // done
+
+	if len(*events) != 1 {
+		t.Fatalf("got %d events, want 1", len(*events))
+	}
+	toplevel := (*events)[0].Toplevel
+	outputs := m.Outputs()
+	if toplevel.OnOutput(outputs[0]) || !toplevel.OnOutput(outputs[1]) {
+		t.Errorf("toplevel on %+v, want it on the second of %+v only", toplevel.Outputs, outputs)
+	}
+
+	// The toplevel stays on its output when the outputs are rearranged
+	// after it entered it.
+	deliver(m, 20, 0, new(request).int(2560).int(0))
+	if !toplevel.OnOutput(m.Outputs()[0]) {
+		t.Error("toplevel lost its output after the outputs were rearranged")
+	}
+}
+
+func TestCovers(t *testing.T) {
+	left, right := Output{id: 10, X: 0}, Output{id: 11, X: 1920}
+
+	tests := []struct {
+		name    string
+		state   State
+		outputs []Output
+		want    []bool // for left and right
+	}{
+		{"maximized", State{Activated: true, Maximized: true}, []Output{right}, []bool{false, true}},
+		{"fullscreen", State{Activated: true, Fullscreen: true}, []Output{left}, []bool{true, false}},
+		{"spanning both outputs", State{Activated: true, Maximized: true}, []Output{left, right}, []bool{true, true}},
+		{"not activated", State{Maximized: true}, []Output{right}, []bool{false, false}},
+		{"not maximized", State{Activated: true}, []Output{right}, []bool{false, false}},
+		{"minimized", State{Activated: true, Minimized: true}, []Output{right}, []bool{false, false}},
+		{"on no output", State{Activated: true, Maximized: true}, nil, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		toplevel := Toplevel{State: tt.state, Outputs: tt.outputs}
+		for i, o := range []Output{left, right} {
+			if got := toplevel.Covers(o); got != tt.want[i] {
+				t.Errorf("%s: Covers(%s) = %v, want %v", tt.name, []string{"left", "right"}[i], got, tt.want[i])
+			}
+		}
+	}
+
+	// Outputs are matched by identity, not by the position the compositor
+	// reported when the toplevel entered them.
+	moved := Output{id: 11, X: 0}
+	maximized := Toplevel{State: State{Activated: true, Maximized: true}, Outputs: []Output{moved}}
+	if maximized.Covers(left) || !maximized.Covers(right) {
+		t.Error("Covers matched outputs by position")
+	}
+}
@@ -0,0 +1,166 @@
+package toplevel
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// conn is a minimal Wayland wire protocol connection. It only supports the
+// argument types used by the interfaces this package binds, which never pass
+// file descriptors.
+type conn struct {
+	sock   *net.UnixConn
+	r      *bufio.Reader
+	wmu    sync.Mutex
+	nextID atomic.Uint32
+}
+
+func dial() (*conn, error) {
+	name := os.Getenv("WAYLAND_DISPLAY")
+	if name == "" {
+		name = "wayland-0"
+	}
+	if !filepath.IsAbs(name) {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			return nil, errors.New("toplevel: XDG_RUNTIME_DIR is not set")
+		}
+		name = filepath.Join(dir, name)
+	}
+
+	sock, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: name, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{sock: sock, r: bufio.NewReader(sock)}
+	// Object ID 1 is always the wl_display.
+	c.nextID.Store(1)
+	return c, nil
+}
+
+// newID allocates a client-side object ID.
+func (c *conn) newID() uint32 {
+	return c.nextID.Add(1)
+}
+
+func (c *conn) close() error {
+	return c.sock.Close()
+}
+
+// request is the encoded argument list of an outgoing message.
+type request struct {
+	buf []byte
+}
+
+func (r *request) uint(v uint32) *request {
+	r.buf = binary.NativeEndian.AppendUint32(r.buf, v)
+	return r
+}
+
+func (r *request) int(v int32) *request {
+	return r.uint(uint32(v))
+}
+
+func (r *request) string(s string) *request {
+	r.uint(uint32(len(s) + 1))
+	r.buf = append(r.buf, s...)
+	r.buf = append(r.buf, 0)
+	return r.pad()
+}
+
+func (r *request) pad() *request {
+	for len(r.buf)%4 != 0 {
+		r.buf = append(r.buf, 0)
+	}
+	return r
+}
+
+// send writes a request with the given opcode to the object id. It is safe
+// to call from any goroutine.
+func (c *conn) send(id uint32, opcode uint16, args *request) error {
+	var body []byte
+	if args != nil {
+		body = args.buf
+	}
+
+	msg := make([]byte, 8, 8+len(body))
+	binary.NativeEndian.PutUint32(msg, id)
+	binary.NativeEndian.PutUint32(msg[4:], uint32(8+len(body))<<16|uint32(opcode))
+	msg = append(msg, body...)
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.sock.Write(msg)
+	return err
+}
+
+// event is an incoming message. Its accessors decode the arguments in order.
+type event struct {
+	sender uint32
+	opcode uint16
+	data   []byte
+}
+
+func (e *event) uint() uint32 {
+	if len(e.data) < 4 {
+		return 0
+	}
+	v := binary.NativeEndian.Uint32(e.data)
+	e.data = e.data[4:]
+	return v
+}
+
+func (e *event) int() int32 {
+	return int32(e.uint())
+}
+
+func (e *event) array() []byte {
+	n := int(e.uint())
+	if n > len(e.data) {
+		n = len(e.data)
+	}
+	v := e.data[:n]
+	e.data = e.data[min((n+3)&^3, len(e.data)):]
+	return v
+}
+
+func (e *event) string() string {
+	v := e.array()
+	if len(v) > 0 && v[len(v)-1] == 0 {
+		v = v[:len(v)-1]
+	}
+	return string(v)
+}
+
+// read blocks until the next event arrives.
+func (c *conn) read() (*event, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return nil, err
+	}
+
+	sizeOpcode := binary.NativeEndian.Uint32(header[4:])
+	size := int(sizeOpcode >> 16)
+	if size < 8 {
+		return nil, fmt.Errorf("toplevel: invalid message size %d", size)
+	}
+
+	e := &event{
+		sender: binary.NativeEndian.Uint32(header[:]),
+		opcode: uint16(sizeOpcode & 0xffff),
+		data:   make([]byte, size-8),
+	}
+	if _, err := io.ReadFull(c.r, e.data); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,171 @@
+package tray
+
+import (
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Handle refers to a tray item and sends requests to it. The requests are
+// D-Bus calls that wait for the reply of the application.
+type Handle struct {
+	conn    *dbus.Conn
+	id      string
+	service string
+	path    dbus.ObjectPath
+
+	// The fields below are guarded by Host.mu.
+	owner      string
+	announced  bool
+	refreshing bool
+	dirty      bool
+	last       Item
+
+	menuMu sync.Mutex
+	menu   dbus.ObjectPath
+}
+
+func (h *Handle) object() dbus.BusObject {
+	return h.conn.Object(h.service, h.path)
+}
+
+// Activate asks the item for its primary action, usually on a left-click at
+// the screen position x, y.
+func (h *Handle) Activate(x, y int) error {
+	return h.object().Call(itemIface+".Activate", 0, int32(x), int32(y)).Err
+}
+
+// SecondaryActivate asks the item for its secondary action, usually on a
+// middle-click.
+func (h *Handle) SecondaryActivate(x, y int) error {
+	return h.object().Call(itemIface+".SecondaryActivate", 0, int32(x), int32(y)).Err
+}
+
+// ContextMenu asks an item without an exported menu to show its own.
+func (h *Handle) ContextMenu(x, y int) error {
+	return h.object().Call(itemIface+".ContextMenu", 0, int32(x), int32(y)).Err
+}
+
+// Scroll reports a mouse wheel movement over the item.
+func (h *Handle) Scroll(delta int, horizontal bool) error {
+	orientation := "vertical"
+	if horizontal {
+		orientation = "horizontal"
+	}
+	return h.object().Call(itemIface+".Scroll", 0, int32(delta), orientation).Err
+}
+
+// rawPixmap is the D-Bus form of a Pixmap, (iiay).
+type rawPixmap struct {
+	Width, Height int32
+	Data          []byte
+}
+
+// rawToolTip is the D-Bus form of a ToolTip, (sa(iiay)ss).
+type rawToolTip struct {
+	IconName    string
+	IconPixmap  []rawPixmap
+	Title       string
+	Description string
+}
+
+// fetch reads all properties of the item.
+func (h *Handle) fetch() (Item, error) {
+	var props map[string]dbus.Variant
+	err := h.object().Call("org.freedesktop.DBus.Properties.GetAll", 0, itemIface).Store(&props)
+	if err != nil {
+		return Item{}, err
+	}
+
+	str := func(name string) string {
+		s, _ := props[name].Value().(string)
+		return s
+	}
+	item := Item{
+		Handle:            h,
+		ID:                str("Id"),
+		Category:          str("Category"),
+		Status:            str("Status"),
+		Title:             str("Title"),
+		IconName:          str("IconName"),
+		IconThemePath:     str("IconThemePath"),
+		AttentionIconName: str("AttentionIconName"),
+		OverlayIconName:   str("OverlayIconName"),
+
+		IconPixmap:          pixmaps(props["IconPixmap"]),
+		AttentionIconPixmap: pixmaps(props["AttentionIconPixmap"]),
+		OverlayIconPixmap:   pixmaps(props["OverlayIconPixmap"]),
+	}
+	if item.Status == "" {
+		item.Status = StatusActive
+	}
+	item.ItemIsMenu, _ = props["ItemIsMenu"].Value().(bool)
+
+	var tip rawToolTip
+	if v, ok := props["ToolTip"]; ok && v.Store(&tip) == nil {
+		item.ToolTip = ToolTip{
+			IconName:    tip.IconName,
+			IconPixmap:  convertPixmaps(tip.IconPixmap),
+			Title:       tip.Title,
+			Description: tip.Description,
+		}
+	}
+
+	menu, _ := props["Menu"].Value().(dbus.ObjectPath)
+	if menu == "/" {
+		menu = ""
+	}
+	item.HasMenu = menu != ""
+	h.menuMu.Lock()
+	h.menu = menu
+	h.menuMu.Unlock()
+
+	return item, nil
+}
+
+func pixmaps(v dbus.Variant) []Pixmap {
+	var raw []rawPixmap
+	if v.Value() == nil || v.Store(&raw) != nil {
+		return nil
+	}
+	return convertPixmaps(raw)
+}
+
+// convertPixmaps drops pixmaps whose data does not match their size.
+func convertPixmaps(raw []rawPixmap) []Pixmap {
+	var pixmaps []Pixmap
+	for _, p := range raw {
+		if p.Width > 0 && p.Height > 0 && len(p.Data) == int(p.Width)*int(p.Height)*4 {
+			pixmaps = append(pixmaps, Pixmap{Width: int(p.Width), Height: int(p.Height), Data: p.Data})
+		}
+	}
+	return pixmaps
+}
+
+// BestPixmap returns the smallest pixmap that is at least size pixels high,
+// or the largest one if all are smaller.
+func BestPixmap(pixmaps []Pixmap, size int) (Pixmap, bool) {
+	var best Pixmap
+	for _, p := range pixmaps {
+		switch {
+		case best.Data == nil,
+			best.Height < size && p.Height > best.Height,
+			p.Height >= size && p.Height < best.Height:
+			best = p
+		}
+	}
+	return best, best.Data != nil
+}
+
+// RGBA returns the pixels with non-premultiplied alpha in RGBA order, as
+// used by GdkPixbuf.
+func (p Pixmap) RGBA() []byte {
+	rgba := make([]byte, len(p.Data))
+	for i := 0; i+3 < len(p.Data); i += 4 {
+		rgba[i] = p.Data[i+1]
+		rgba[i+1] = p.Data[i+2]
+		rgba[i+2] = p.Data[i+3]
+		rgba[i+3] = p.Data[i]
+	}
+	return rgba
+}
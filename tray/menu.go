@@ -0,0 +1,136 @@
+package tray
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const menuIface = "com.canonical.dbusmenu"
+
+// menuTimeout limits how long Menu waits for the application, as the menu
+// is requested while the user waits for it to pop up.
+const menuTimeout = time.Second
+
+// ErrNoMenu is returned by Menu for items that do not export a menu.
+var ErrNoMenu = errors.New("tray: item has no menu")
+
+// Menu events sent with MenuEvent.
+const (
+	MenuClicked = "clicked"
+	MenuOpened  = "opened"
+	MenuClosed  = "closed"
+)
+
+// MenuItem is an entry of the DBusMenu of an item. The root item holds the
+// top-level entries as its children.
+type MenuItem struct {
+	ID int32
+	// Label marks its mnemonic with an underscore.
+	Label     string
+	Separator bool
+	Enabled   bool
+	Visible   bool
+	IconName  string
+	// IconData is a PNG image.
+	IconData []byte
+	// ToggleType is "checkmark", "radio" or empty.
+	ToggleType string
+	// ToggleState is 1 when checked, 0 when not and -1 when unknown.
+	ToggleState int32
+	Children    []MenuItem
+}
+
+// menuLayout is the D-Bus form of a menu item, (ia{sv}av).
+type menuLayout struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+	Children   []dbus.Variant
+}
+
+func (h *Handle) menuObject() (dbus.BusObject, error) {
+	h.menuMu.Lock()
+	defer h.menuMu.Unlock()
+	if h.menu == "" {
+		return nil, ErrNoMenu
+	}
+	return h.conn.Object(h.service, h.menu), nil
+}
+
+// Menu reads the whole menu of the item. It waits at most a second for the
+// application.
+func (h *Handle) Menu() (MenuItem, error) {
+	obj, err := h.menuObject()
+	if err != nil {
+		return MenuItem{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), menuTimeout)
+	defer cancel()
+
+	// Give the application a chance to fill the menu. Not all of them
+	// implement this.
+	obj.CallWithContext(ctx, menuIface+".AboutToShow", 0, int32(0))
+
+	var revision uint32
+	var layout menuLayout
+	err = obj.CallWithContext(ctx, menuIface+".GetLayout", 0, int32(0), int32(-1), []string{}).Store(&revision, &layout)
+	if err != nil {
+		return MenuItem{}, err
+	}
+	return layout.item(), nil
+}
+
+// MenuEvent reports an event, such as MenuClicked, on the menu entry id.
+func (h *Handle) MenuEvent(id int32, event string) error {
+	obj, err := h.menuObject()
+	if err != nil {
+		return err
+	}
+	timestamp := uint32(time.Now().Unix())
+	return obj.Call(menuIface+".Event", 0, id, event, dbus.MakeVariant(int32(0)), timestamp).Err
+}
+
+func (l menuLayout) item() MenuItem {
+	item := MenuItem{ID: l.ID, Enabled: true, Visible: true, ToggleState: -1}
+	for name, v := range l.Properties {
+		switch value := v.Value().(type) {
+		case string:
+			switch name {
+			case "type":
+				item.Separator = value == "separator"
+			case "label":
+				item.Label = value
+			case "icon-name":
+				item.IconName = value
+			case "toggle-type":
+				item.ToggleType = value
+			}
+		case bool:
+			switch name {
+			case "enabled":
+				item.Enabled = value
+			case "visible":
+				item.Visible = value
+			}
+		case int32:
+			if name == "toggle-state" {
+				item.ToggleState = value
+			}
+		case []byte:
+			if name == "icon-data" {
+				item.IconData = value
+			}
+		}
+	}
+
+	for _, v := range l.Children {
+		var child menuLayout
+		if v.Store(&child) == nil {
+			item.Children = append(item.Children, child.item())
+		}
+	}
+	return item
+}
@@ -0,0 +1,361 @@
+// Package tray implements the StatusNotifierItem system tray over D-Bus. It
+// provides a StatusNotifierWatcher when no other process does, and a host
+// that tracks the registered items and reports every change as an event,
+// so that a bar can update its tray icons in place.
+package tray
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Bus names, object paths and interfaces of the protocol.
+const (
+	watcherName  = "org.kde.StatusNotifierWatcher"
+	watcherPath  = "/StatusNotifierWatcher"
+	watcherIface = "org.kde.StatusNotifierWatcher"
+	itemIface    = "org.kde.StatusNotifierItem"
+	itemPath     = "/StatusNotifierItem"
+)
+
+// Item statuses.
+const (
+	StatusPassive        = "Passive"
+	StatusActive         = "Active"
+	StatusNeedsAttention = "NeedsAttention"
+)
+
+// Pixmap is an icon image sent by an item, in ARGB32 with the bytes in
+// network order.
+type Pixmap struct {
+	Width, Height int
+	Data          []byte
+}
+
+// ToolTip is the tooltip of an item.
+type ToolTip struct {
+	IconName    string
+	IconPixmap  []Pixmap
+	Title       string
+	Description string
+}
+
+// Item is a snapshot of a tray item.
+type Item struct {
+	Handle *Handle
+
+	ID       string
+	Category string
+	Status   string
+	Title    string
+
+	IconName            string
+	IconThemePath       string
+	IconPixmap          []Pixmap
+	AttentionIconName   string
+	AttentionIconPixmap []Pixmap
+	OverlayIconName     string
+	OverlayIconPixmap   []Pixmap
+
+	ToolTip ToolTip
+	// ItemIsMenu items only show their menu when activated.
+	ItemIsMenu bool
+	HasMenu    bool
+}
+
+// EventKind tells what happened to an item.
+type EventKind int
+
+const (
+	// Added is sent once the properties of a new item have been read.
+	Added EventKind = iota
+	// Changed is sent after the properties of an item changed.
+	Changed
+	// Removed is sent when an item has gone away. Its handle must not be
+	// used anymore.
+	Removed
+)
+
+// Event reports a change of an item together with its new state.
+type Event struct {
+	Kind EventKind
+	Item Item
+}
+
+// Host is a connection to the session bus that tracks the tray items.
+type Host struct {
+	conn    *dbus.Conn
+	handler func(Event)
+	name    string
+
+	// watcher is our own watcher, or nil while another process provides
+	// it. It is only accessed from the dispatch goroutine after Connect.
+	watcher *watcher
+
+	// mu guards items and the state of the handles, and serializes the
+	// calls of handler.
+	mu    sync.Mutex
+	items map[string]*Handle
+
+	closeOnce sync.Once
+}
+
+// Connect connects to the session bus and starts tracking tray items.
+// handler is called from background goroutines for every event, one at a
+// time and in order for each item; it must not block. Items that already
+// exist are reported as Added.
+func Connect(handler func(Event)) (*Host, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Host{
+		conn:    conn,
+		handler: handler,
+		name:    fmt.Sprintf("org.kde.StatusNotifierHost-%d", os.Getpid()),
+		items:   make(map[string]*Handle),
+	}
+
+	matches := [][]dbus.MatchOption{
+		{
+			dbus.WithMatchSender("org.freedesktop.DBus"),
+			dbus.WithMatchInterface("org.freedesktop.DBus"),
+			dbus.WithMatchMember("NameOwnerChanged"),
+		},
+		{dbus.WithMatchInterface(watcherIface)},
+		{dbus.WithMatchInterface(itemIface)},
+	}
+	for _, match := range matches {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	signals := make(chan *dbus.Signal, 64)
+	conn.Signal(signals)
+
+	if h.watcher, err = startWatcher(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := conn.RequestName(h.name, dbus.NameFlagDoNotQueue); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := h.register(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go h.dispatch(signals)
+	return h, nil
+}
+
+// Close stops tracking items and disconnects from the session bus.
+func (h *Host) Close() {
+	h.closeOnce.Do(func() {
+		h.conn.Close()
+	})
+}
+
+// register registers the host with the current watcher and adds the items
+// it already knows.
+func (h *Host) register() error {
+	obj := h.conn.Object(watcherName, watcherPath)
+	if err := obj.Call(watcherIface+".RegisterStatusNotifierHost", 0, h.name).Err; err != nil {
+		return err
+	}
+
+	v, err := obj.GetProperty(watcherIface + ".RegisteredStatusNotifierItems")
+	if err != nil {
+		return err
+	}
+	var ids []string
+	if err := v.Store(&ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		h.add(id)
+	}
+	return nil
+}
+
+func (h *Host) dispatch(signals <-chan *dbus.Signal) {
+	// The channel is closed together with the connection.
+	for s := range signals {
+		switch {
+		case s.Name == "org.freedesktop.DBus.NameOwnerChanged":
+			var name, oldOwner, newOwner string
+			if dbus.Store(s.Body, &name, &oldOwner, &newOwner) != nil {
+				continue
+			}
+			h.nameOwnerChanged(name, newOwner)
+		case s.Name == watcherIface+".StatusNotifierItemRegistered":
+			if id, ok := firstString(s.Body); ok {
+				h.add(id)
+			}
+		case s.Name == watcherIface+".StatusNotifierItemUnregistered":
+			if id, ok := firstString(s.Body); ok {
+				h.remove(id)
+			}
+		case strings.HasPrefix(s.Name, itemIface+"."):
+			// NewTitle, NewIcon, NewToolTip, NewStatus and friends: read
+			// all properties again.
+			h.mu.Lock()
+			for _, handle := range h.items {
+				if handle.owner == s.Sender && handle.path == s.Path {
+					go h.refresh(handle)
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+func (h *Host) nameOwnerChanged(name, newOwner string) {
+	if h.watcher != nil {
+		h.watcher.nameOwnerChanged(name, newOwner)
+	}
+
+	if name == watcherName && h.watcher == nil {
+		if newOwner == "" {
+			// The other watcher is gone, so take over.
+			w, err := startWatcher(h.conn)
+			if err != nil {
+				log.Println("Error starting the tray watcher:", err)
+				return
+			}
+			h.watcher = w
+		}
+		if err := h.register(); err != nil {
+			log.Println("Error registering the tray host:", err)
+		}
+		return
+	}
+
+	if newOwner != "" {
+		return
+	}
+	var gone []string
+	h.mu.Lock()
+	for id, handle := range h.items {
+		if handle.service == name || handle.owner == name {
+			gone = append(gone, id)
+		}
+	}
+	h.mu.Unlock()
+	for _, id := range gone {
+		h.remove(id)
+	}
+}
+
+func (h *Host) add(id string) {
+	service, path := splitID(id)
+	h.mu.Lock()
+	if _, ok := h.items[id]; ok {
+		h.mu.Unlock()
+		return
+	}
+	handle := &Handle{conn: h.conn, id: id, service: service, path: path, owner: service}
+	h.items[id] = handle
+	h.mu.Unlock()
+
+	go h.load(handle)
+}
+
+// load reads a new item for the first time.
+func (h *Host) load(handle *Handle) {
+	// Signals carry the unique name of the sender, while items may be
+	// registered under a well-known name.
+	if !strings.HasPrefix(handle.service, ":") {
+		var owner string
+		err := h.conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, handle.service).Store(&owner)
+		if err != nil {
+			log.Printf("Tray item %s has no owner: %v", handle.id, err)
+			h.remove(handle.id)
+			return
+		}
+		h.mu.Lock()
+		handle.owner = owner
+		h.mu.Unlock()
+	}
+
+	h.refresh(handle)
+}
+
+func (h *Host) remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	handle, ok := h.items[id]
+	if !ok {
+		return
+	}
+	delete(h.items, id)
+	if handle.announced {
+		h.handler(Event{Kind: Removed, Item: handle.last})
+	}
+}
+
+// refresh reads the properties of an item and reports them. Refreshes of
+// the same item never overlap; a refresh requested while one is running
+// makes it read the properties again.
+func (h *Host) refresh(handle *Handle) {
+	h.mu.Lock()
+	if handle.refreshing {
+		handle.dirty = true
+		h.mu.Unlock()
+		return
+	}
+	handle.refreshing = true
+	h.mu.Unlock()
+
+	item, err := handle.fetch()
+	h.mu.Lock()
+	for handle.dirty {
+		handle.dirty = false
+		h.mu.Unlock()
+		item, err = handle.fetch()
+		h.mu.Lock()
+	}
+	handle.refreshing = false
+	defer h.mu.Unlock()
+
+	if h.items[handle.id] != handle {
+		return
+	}
+	if err != nil {
+		log.Printf("Error reading tray item %s: %v", handle.id, err)
+		return
+	}
+	kind := Changed
+	if !handle.announced {
+		kind = Added
+		handle.announced = true
+	}
+	handle.last = item
+	h.handler(Event{Kind: kind, Item: item})
+}
+
+// splitID splits an item as registered with the watcher, such as
+// ":1.42/StatusNotifierItem", into its bus name and object path.
+func splitID(id string) (string, dbus.ObjectPath) {
+	if i := strings.IndexByte(id, '/'); i >= 0 {
+		return id[:i], dbus.ObjectPath(id[i:])
+	}
+	return id, itemPath
+}
+
+func firstString(body []interface{}) (string, bool) {
+	if len(body) == 0 {
+		return "", false
+	}
+	s, ok := body[0].(string)
+	return s, ok
+}
@@ -0,0 +1,157 @@
+package tray
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+var watcherIntrospection = introspect.Interface{
+	Name: watcherIface,
+	Methods: []introspect.Method{
+		{Name: "RegisterStatusNotifierItem", Args: []introspect.Arg{{Name: "service", Type: "s", Direction: "in"}}},
+		{Name: "RegisterStatusNotifierHost", Args: []introspect.Arg{{Name: "service", Type: "s", Direction: "in"}}},
+	},
+	Signals: []introspect.Signal{
+		{Name: "StatusNotifierItemRegistered", Args: []introspect.Arg{{Type: "s"}}},
+		{Name: "StatusNotifierItemUnregistered", Args: []introspect.Arg{{Type: "s"}}},
+		{Name: "StatusNotifierHostRegistered"},
+		{Name: "StatusNotifierHostUnregistered"},
+	},
+	Properties: []introspect.Property{
+		{Name: "RegisteredStatusNotifierItems", Type: "as", Access: "read"},
+		{Name: "IsStatusNotifierHostRegistered", Type: "b", Access: "read"},
+		{Name: "ProtocolVersion", Type: "i", Access: "read"},
+	},
+}
+
+// watcher is the StatusNotifierWatcher service. Items register with it,
+// and hosts learn about the items from it.
+type watcher struct {
+	conn  *dbus.Conn
+	props *prop.Properties
+
+	mu    sync.Mutex
+	items []string
+	hosts []string
+}
+
+// startWatcher provides the watcher service on conn. It returns nil without
+// an error if another process already provides it.
+func startWatcher(conn *dbus.Conn) (*watcher, error) {
+	w := &watcher{conn: conn}
+
+	if err := conn.Export(w, watcherPath, watcherIface); err != nil {
+		return nil, err
+	}
+	props, err := prop.Export(conn, watcherPath, prop.Map{
+		watcherIface: {
+			"RegisteredStatusNotifierItems":  {Value: []string{}, Emit: prop.EmitTrue},
+			"IsStatusNotifierHostRegistered": {Value: false, Emit: prop.EmitTrue},
+			"ProtocolVersion":                {Value: int32(0), Emit: prop.EmitConst},
+		},
+	})
+	if err != nil {
+		w.unexport()
+		return nil, err
+	}
+	w.props = props
+	node := &introspect.Node{
+		Name:       watcherPath,
+		Interfaces: []introspect.Interface{introspect.IntrospectData, prop.IntrospectData, watcherIntrospection},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), watcherPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		w.unexport()
+		return nil, err
+	}
+
+	reply, err := conn.RequestName(watcherName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		w.unexport()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *watcher) unexport() {
+	w.conn.Export(nil, watcherPath, watcherIface)
+	w.conn.Export(nil, watcherPath, "org.freedesktop.DBus.Properties")
+	w.conn.Export(nil, watcherPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// RegisterStatusNotifierItem registers an item. service is either the bus
+// name of the item, which then lives at /StatusNotifierItem, or its object
+// path on the bus connection of the caller.
+func (w *watcher) RegisterStatusNotifierItem(sender dbus.Sender, service string) *dbus.Error {
+	id := service + itemPath
+	if strings.HasPrefix(service, "/") {
+		id = string(sender) + service
+	}
+
+	w.mu.Lock()
+	if slices.Contains(w.items, id) {
+		w.mu.Unlock()
+		return nil
+	}
+	w.items = append(w.items, id)
+	items := slices.Clone(w.items)
+	w.mu.Unlock()
+
+	w.props.SetMust(watcherIface, "RegisteredStatusNotifierItems", items)
+	w.conn.Emit(watcherPath, watcherIface+".StatusNotifierItemRegistered", id)
+	return nil
+}
+
+// RegisterStatusNotifierHost registers a host. Hosts are tracked by the
+// connection of the caller.
+func (w *watcher) RegisterStatusNotifierHost(sender dbus.Sender, service string) *dbus.Error {
+	w.mu.Lock()
+	if slices.Contains(w.hosts, string(sender)) {
+		w.mu.Unlock()
+		return nil
+	}
+	w.hosts = append(w.hosts, string(sender))
+	w.mu.Unlock()
+
+	w.props.SetMust(watcherIface, "IsStatusNotifierHostRegistered", true)
+	w.conn.Emit(watcherPath, watcherIface+".StatusNotifierHostRegistered")
+	return nil
+}
+
+// nameOwnerChanged unregisters the items and hosts of a bus name that went
+// away.
+func (w *watcher) nameOwnerChanged(name, newOwner string) {
+	if newOwner != "" {
+		return
+	}
+
+	w.mu.Lock()
+	var gone []string
+	w.items = slices.DeleteFunc(w.items, func(id string) bool {
+		if service, _ := splitID(id); service == name {
+			gone = append(gone, id)
+			return true
+		}
+		return false
+	})
+	items := slices.Clone(w.items)
+	hostGone := slices.Contains(w.hosts, name)
+	w.hosts = slices.DeleteFunc(w.hosts, func(host string) bool { return host == name })
+	hosts := len(w.hosts)
+	w.mu.Unlock()
+
+	if len(gone) > 0 {
+		w.props.SetMust(watcherIface, "RegisteredStatusNotifierItems", items)
+		for _, id := range gone {
+			w.conn.Emit(watcherPath, watcherIface+".StatusNotifierItemUnregistered", id)
+		}
+	}
+	if hostGone {
+		w.props.SetMust(watcherIface, "IsStatusNotifierHostRegistered", hosts > 0)
+		w.conn.Emit(watcherPath, watcherIface+".StatusNotifierHostUnregistered")
+	}
+}
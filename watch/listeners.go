@@ -0,0 +1,76 @@
+// Package watch helps services tell their users about changes.
+package watch
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Listeners is a set of callbacks that are called with every value passed
+// to Notify. The zero value is an empty set. It is safe for concurrent use,
+// and callbacks may add and remove callbacks.
+type Listeners[T any] struct {
+	notifyMu sync.Mutex
+
+	mu   sync.Mutex
+	fns  map[int]func(T)
+	next int
+}
+
+// Add adds fn and returns a function that removes it again.
+func (l *Listeners[T]) Add(fn func(T)) (remove func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.fns == nil {
+		l.fns = make(map[int]func(T))
+	}
+	id := l.next
+	l.next++
+	l.fns[id] = fn
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.fns, id)
+	}
+}
+
+// Notify calls the callbacks with v in the order they were added. Callbacks
+// removed meanwhile are skipped, and added ones are only called by the next
+// Notify. Calls of Notify from several goroutines do not overlap.
+func (l *Listeners[T]) Notify(v T) {
+	l.notifyMu.Lock()
+	defer l.notifyMu.Unlock()
+
+	l.mu.Lock()
+	ids := slices.Sorted(maps.Keys(l.fns))
+	l.mu.Unlock()
+
+	for _, id := range ids {
+		l.mu.Lock()
+		fn, ok := l.fns[id]
+		l.mu.Unlock()
+		if ok {
+			fn(v)
+		}
+	}
+}
+
+// Coalesce returns a function that calls fn from a background goroutine
+// delay after it was first called, once for all calls in between. Services
+// often send several signals for one change.
+func Coalesce(delay time.Duration, fn func()) func() {
+	var pending atomic.Bool
+	return func() {
+		if pending.Swap(true) {
+			return
+		}
+		time.AfterFunc(delay, func() {
+			pending.Store(false)
+			fn()
+		})
+	}
+}
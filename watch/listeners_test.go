@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestListeners(t *testing.T) {
+	var l Listeners[int]
+	var got []string
+	record := func(name string) func(int) {
+		return func(v int) { got = append(got, name+string(rune('0'+v))) }
+	}
+
+	l.Add(record("a"))
+	var removeB func()
+	removeB = l.Add(func(v int) {
+		got = append(got, "b"+string(rune('0'+v)))
+		// Removing itself and adding another one must not deadlock.
+		removeB()
+		l.Add(record("d"))
+	})
+	removeC := l.Add(record("c"))
+
+	l.Notify(1)
+	removeC()
+	l.Notify(2)
+
+	want := []string{"a1", "b1", "c1", "a2", "d2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("callbacks called as %v, want %v", got, want)
+	}
+}
+
+func TestListenersRemovedDuringNotify(t *testing.T) {
+	var l Listeners[struct{}]
+	var removeB func()
+	called := false
+	l.Add(func(struct{}) { removeB() })
+	removeB = l.Add(func(struct{}) { called = true })
+
+	l.Notify(struct{}{})
+	if called {
+		t.Error("a callback removed by an earlier one was called")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{}, 2)
+	changed := Coalesce(10*time.Millisecond, func() {
+		calls.Add(1)
+		done <- struct{}{}
+	})
+
+	for range 5 {
+		changed()
+	}
+	<-done
+	changed()
+	<-done
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("fn called %d times, want 2", n)
+	}
+}